@@ -0,0 +1,235 @@
+package autumn
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type lookupLifecycle struct {
+	Tree  *Tree
+	found interface{}
+}
+
+func (l *lookupLifecycle) PostConstruct() {
+	l.found = l.Tree.GetLeafValue("memory")
+}
+
+func TestConcurrency(t *testing.T) {
+	Convey("Handles concurrent use", t, func() {
+
+		Convey("Looks up leaves while others are registered and the tree is grown", func() {
+			tree := NewTree().
+				AddNamedLeaf("memory", &memoryStore{}).
+				AddAlias("memory", "cache").
+				AddNamedLeaf("child", &child{}, Prototype()).
+				Grow()
+
+			failures := make(chan error, 1000)
+			group := &sync.WaitGroup{}
+			for i := 0; i < 10; i++ {
+				group.Add(2)
+
+				go func(i int) {
+					defer group.Done()
+					for j := 0; j < 20; j++ {
+						name := fmt.Sprintf("plugin-%d-%d", i, j)
+						if err := tree.AddNamedLeafE(name, &storeUser{}, Groups("plugins")); err != nil {
+							failures <- err
+						}
+						if err := tree.GrowE(); err != nil {
+							failures <- err
+						}
+					}
+				}(i)
+
+				go func() {
+					defer group.Done()
+					for j := 0; j < 20; j++ {
+						if tree.GetLeafValue("cache") == nil || tree.Describe("memory") == nil {
+							failures <- fmt.Errorf("leaf lookup failed")
+						}
+						if _, err := Get[*child](tree, "child"); err != nil {
+							failures <- err
+						}
+						tree.Leaves()
+						tree.Graph()
+						tree.ActiveProfiles()
+					}
+				}()
+			}
+			group.Wait()
+			close(failures)
+
+			for err := range failures {
+				So(err, ShouldBeNil)
+			}
+			So(tree.Leaves(), ShouldHaveLength, 202)
+		})
+
+		Convey("Creates prototypes from several goroutines at once", func() {
+			tree := NewTree().
+				AddNamedLeaf("child", &child{}, Prototype()).
+				AddLeaf(&memoryStore{}).
+				AddProvider(func(*memoryStore) *diskStore { return &diskStore{} }, Prototype()).
+				Grow()
+
+			failures := make(chan error, 200)
+			group := &sync.WaitGroup{}
+			for i := 0; i < 100; i++ {
+				group.Add(1)
+				go func() {
+					defer group.Done()
+					if _, err := Get[*child](tree, "child"); err != nil {
+						failures <- err
+					}
+					if _, err := GetByType[*diskStore](tree); err != nil {
+						failures <- err
+					}
+				}()
+			}
+			group.Wait()
+			close(failures)
+
+			for err := range failures {
+				So(err, ShouldBeNil)
+			}
+		})
+
+		Convey("Creates prototypes concurrently without constructing the singletons they depend on", func() {
+			tree := NewTree().
+				AddProvider(func(*memoryStore) *diskStore { return &diskStore{} }, Prototype()).
+				AddProvider(func() *memoryStore { return &memoryStore{} })
+
+			failures := make(chan error, 8)
+			group := &sync.WaitGroup{}
+			for i := 0; i < 8; i++ {
+				group.Add(1)
+				go func() {
+					defer group.Done()
+					if _, err := GetByType[*diskStore](tree); !errors.Is(err, ErrNotConstructed) {
+						failures <- fmt.Errorf("expected the lookup to fail, got %v", err)
+					}
+				}()
+			}
+			group.Wait()
+			close(failures)
+
+			for err := range failures {
+				So(err, ShouldBeNil)
+			}
+			So(tree.GrowE(), ShouldBeNil)
+			_, err := GetByType[*diskStore](tree)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Lets prototype PostConstruct functions use the tree while leaves are registered", func() {
+			tree := NewTree().AddNamedLeaf("memory", &memoryStore{}).Grow()
+			tree.AddNamedLeaf("lookup", &lookupLifecycle{Tree: tree}, Prototype()).Grow()
+
+			// Keep registering leaves until the lookups are done, so writers are often waiting for the lock
+			done := make(chan struct{})
+			registered := &sync.WaitGroup{}
+			registered.Add(1)
+			go func() {
+				defer registered.Done()
+				for i := 0; ; i++ {
+					select {
+					case <-done:
+						return
+					default:
+						tree.AddNamedLeaf(fmt.Sprintf("plugin-%d", i), &noop{})
+					}
+				}
+			}()
+
+			failures := make(chan error, 1000)
+			group := &sync.WaitGroup{}
+			for i := 0; i < 10; i++ {
+				group.Add(1)
+				go func() {
+					defer group.Done()
+					for j := 0; j < 50; j++ {
+						lookup, err := Get[*lookupLifecycle](tree, "lookup")
+						if err != nil {
+							failures <- err
+						} else if lookup.found == nil || tree.GetLeafValue("lookup").(*lookupLifecycle).found == nil {
+							failures <- fmt.Errorf("prototype lookup failed")
+						}
+					}
+				}()
+			}
+			group.Wait()
+			close(done)
+			registered.Wait()
+			close(failures)
+
+			for err := range failures {
+				So(err, ShouldBeNil)
+			}
+		})
+
+		Convey("Looks up leaves while the tree is refreshed", func() {
+			tree := NewTree().
+				AddLeaf(&orderRecorder{}, KeepOnRefresh()).
+				AddLeaf(&refreshedServer{}).
+				AddProvider(func(server *refreshedServer) *refreshedClient {
+					return &refreshedClient{Server: server}
+				}).
+				Grow()
+
+			done := make(chan struct{})
+			failures := make(chan error, 1000)
+			group := &sync.WaitGroup{}
+			for i := 0; i < 4; i++ {
+				group.Add(1)
+				go func() {
+					defer group.Done()
+					for {
+						select {
+						case <-done:
+							return
+						default:
+						}
+						if _, err := Get[*refreshedClient](tree, "autumn.refreshedClient"); err != nil {
+							failures <- err
+							return
+						}
+						if _, err := tree.GetLeafValueE("autumn.refreshedServer"); err != nil {
+							failures <- err
+							return
+						}
+					}
+				}()
+			}
+			for i := 0; i < 200; i++ {
+				So(tree.RefreshE(), ShouldBeNil)
+			}
+			close(done)
+			group.Wait()
+			close(failures)
+
+			for err := range failures {
+				So(err, ShouldBeNil)
+			}
+		})
+
+		Convey("Lets PostConstruct functions and conditions use the tree", func() {
+			tree := NewTree()
+			lookup := &lookupLifecycle{Tree: tree}
+			tree.
+				AddNamedLeaf("memory", &memoryStore{}).
+				AddNamedLeaf("lookup", lookup).
+				AddNamedLeaf("disk", &diskStore{}, OnCondition("memory exists", func(tree *Tree) bool {
+					return tree.GetLeafValue("memory") != nil
+				})).
+				Grow()
+
+			So(lookup.found, ShouldEqual, tree.GetLeafValue("memory"))
+			So(tree.GetLeaf("disk"), ShouldNotBeNil)
+		})
+	})
+}
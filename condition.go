@@ -0,0 +1,121 @@
+package autumn
+
+import (
+	"fmt"
+	"strings"
+)
+
+// condition decides whether a conditional leaf should be added to the tree as it's grown, returning the reason for
+// the decision
+type condition func(tree *Tree, leaf *leaf) (bool, string)
+
+// ConditionResult describes the outcome of a single condition on a leaf
+type ConditionResult struct {
+	Matched bool
+	Reason  string
+}
+
+// ConditionOutcome describes why a conditional leaf was or wasn't added to the tree
+type ConditionOutcome struct {
+	Leaf       string
+	Included   bool
+	Conditions []ConditionResult
+}
+
+// ConditionReport describes the conditional leaves evaluated while growing a tree, in the order they were evaluated
+type ConditionReport []ConditionOutcome
+
+// String lists the included leaves followed by the excluded ones, along with the outcome of each of their conditions
+func (r ConditionReport) String() string {
+	message := ""
+	for _, included := range []bool{true, false} {
+		if included {
+			message += "Included leaves:"
+		} else {
+			message += "\nExcluded leaves:"
+		}
+
+		for _, outcome := range r {
+			if outcome.Included != included {
+				continue
+			}
+			message += "\n- " + outcome.Leaf
+			for _, result := range outcome.Conditions {
+				if result.Matched {
+					message += "\n    - matched: " + result.Reason
+				} else {
+					message += "\n    - did not match: " + result.Reason
+				}
+			}
+		}
+	}
+	return message
+}
+
+// evaluateConditions evaluates every condition on the leaf, reporting whether they all matched
+func (l *leaf) evaluateConditions(tree *Tree) ConditionOutcome {
+	outcome := ConditionOutcome{Leaf: l.name, Included: true, Conditions: make([]ConditionResult, 0, len(l.conditions))}
+	for _, condition := range l.conditions {
+		matched, reason := condition(tree, l)
+		outcome.Conditions = append(outcome.Conditions, ConditionResult{Matched: matched, Reason: reason})
+		outcome.Included = outcome.Included && matched
+	}
+	return outcome
+}
+
+// profileCondition matches when one of the supplied profiles is active
+func profileCondition(profiles []string) condition {
+	return func(tree *Tree, leaf *leaf) (bool, string) {
+		if profilesMatch(profiles, tree.ActiveProfiles()) {
+			return true, "one of the profiles " + strings.Join(profiles, ", ") + " is active"
+		}
+		return false, "none of the profiles " + strings.Join(profiles, ", ") + " are active"
+	}
+}
+
+// propertyCondition matches when the property is set, and has one of the supplied values if there are any
+func propertyCondition(key string, values []string) condition {
+	return func(tree *Tree, leaf *leaf) (bool, string) {
+		value, ok := tree.environment.Property(key)
+		if !ok {
+			return false, "property " + key + " is not set"
+		} else if len(values) == 0 {
+			return true, "property " + key + " is set"
+		} else if containsString(values, value) {
+			return true, fmt.Sprintf("property %s is %q", key, value)
+		}
+		return false, fmt.Sprintf("property %s is %q, not one of %q", key, value, values)
+	}
+}
+
+// leafCondition matches when all of the named leaves are present, or all of them are missing. The leaf's own name is
+// used if no names are supplied
+func leafCondition(names []string, present bool) condition {
+	return func(tree *Tree, leaf *leaf) (bool, string) {
+		checked := names
+		if len(checked) == 0 {
+			checked = []string{leaf.name}
+		}
+
+		for _, name := range checked {
+			if (tree.GetLeaf(name) != nil) != present {
+				if present {
+					return false, "leaf " + name + " is missing"
+				}
+				return false, "leaf " + name + " is present"
+			}
+		}
+
+		if present {
+			return true, "leaves " + strings.Join(checked, ", ") + " are present"
+		}
+		return true, "leaves " + strings.Join(checked, ", ") + " are missing"
+	}
+}
+
+// predicateCondition matches when the supplied predicate returns true
+func predicateCondition(description string, predicate func(tree *Tree) bool) condition {
+	return func(tree *Tree, leaf *leaf) (bool, string) {
+		return predicate(tree), description
+	}
+}
@@ -0,0 +1,50 @@
+package autumn
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConditions(t *testing.T) {
+	Convey("Evaluates conditions", t, func() {
+		tree := NewTree().SetProperty("store.type", "disk").AddNamedLeaf("disk", &diskStore{})
+		leaf := mustLeaf(newNamedLeaf(tree.config, "memory", &memoryStore{}))
+
+		Convey("Explains property conditions", func() {
+			matched, reason := propertyCondition("store.type", []string{"memory"})(tree, leaf)
+			So(matched, ShouldBeFalse)
+			So(reason, ShouldEqual, `property store.type is "disk", not one of ["memory"]`)
+
+			matched, reason = propertyCondition("store.size", nil)(tree, leaf)
+			So(matched, ShouldBeFalse)
+			So(reason, ShouldEqual, "property store.size is not set")
+		})
+
+		Convey("Explains leaf conditions", func() {
+			matched, reason := leafCondition(nil, false)(tree, leaf)
+			So(matched, ShouldBeTrue)
+			So(reason, ShouldEqual, "leaves memory are missing")
+
+			matched, reason = leafCondition([]string{"disk"}, false)(tree, leaf)
+			So(matched, ShouldBeFalse)
+			So(reason, ShouldEqual, "leaf disk is present")
+		})
+	})
+}
+
+func TestConditionReport(t *testing.T) {
+	Convey("Describes the condition outcomes", t, func() {
+		report := ConditionReport{
+			{Leaf: "memory", Included: false, Conditions: []ConditionResult{{Matched: false, Reason: "leaf disk is present"}}},
+			{Leaf: "cache", Included: true, Conditions: []ConditionResult{{Matched: true, Reason: "property cache is set"}}},
+		}
+
+		So(report.String(), ShouldEqual, "Included leaves:"+
+			"\n- cache"+
+			"\n    - matched: property cache is set"+
+			"\nExcluded leaves:"+
+			"\n- memory"+
+			"\n    - did not match: leaf disk is present")
+	})
+}
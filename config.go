@@ -1,13 +1,21 @@
 package autumn
 
-import "unicode"
+import (
+	"time"
+	"unicode"
+)
 
 // config defines the configuration structure for autumn
 type config struct {
 	tagName             string
 	leafNameMethod      string
+	leafGroupsMethod    string
 	postConstructMethod string
 	preDestroyMethod    string
+
+	postConstructTimeout time.Duration
+	preDestroyTimeout    time.Duration
+	shutdownTimeout      time.Duration
 }
 
 // NewConfig creates a new configuration object
@@ -15,8 +23,10 @@ func NewConfig() *config {
 	return &config{
 		tagName:             "autumn",
 		leafNameMethod:      "GetLeafName",
+		leafGroupsMethod:    "GetLeafGroups",
 		postConstructMethod: "PostConstruct",
 		preDestroyMethod:    "PreDestroy",
+		shutdownTimeout:     30 * time.Second,
 	}
 }
 
@@ -36,6 +46,13 @@ func (c *config) LeafNameMethod(method string) *config {
 	return c
 }
 
+// LeafGroupsMethod sets the method name for getting the groups a leaf belongs to
+func (c *config) LeafGroupsMethod(method string) *config {
+	c.ensurePublicMethod(method)
+	c.leafGroupsMethod = method
+	return c
+}
+
 // PostConstructMethod sets the method name for post construct calls
 func (c *config) PostConstructMethod(method string) *config {
 	c.ensurePublicMethod(method)
@@ -50,6 +67,37 @@ func (c *config) PreDestroyMethod(method string) *config {
 	return c
 }
 
+// PostConstructTimeout sets how long to wait for each leaf's post construct call before failing the grow. A zero
+// duration (the default) waits forever, and individual leaves can override the timeout
+func (c *config) PostConstructTimeout(timeout time.Duration) *config {
+	c.ensureTimeout(timeout)
+	c.postConstructTimeout = timeout
+	return c
+}
+
+// PreDestroyTimeout sets how long to wait for each leaf's pre destroy call before moving on to the next leaf. A zero
+// duration (the default) waits forever, and individual leaves can override the timeout
+func (c *config) PreDestroyTimeout(timeout time.Duration) *config {
+	c.ensureTimeout(timeout)
+	c.preDestroyTimeout = timeout
+	return c
+}
+
+// ShutdownTimeout sets how long Run gives the tree to be chopped down once it's been asked to stop, after which the
+// remaining PreDestroy calls are reported as hung. Defaults to 30 seconds, and a zero duration waits forever
+func (c *config) ShutdownTimeout(timeout time.Duration) *config {
+	c.ensureTimeout(timeout)
+	c.shutdownTimeout = timeout
+	return c
+}
+
+// ensureTimeout ensures the supplied timeout isn't negative
+func (c *config) ensureTimeout(timeout time.Duration) {
+	if timeout < 0 {
+		panic("The timeout cannot be negative")
+	}
+}
+
 // ensurePublicMethod ensures the supplied method name is public
 func (c *config) ensurePublicMethod(method string) {
 
@@ -2,6 +2,7 @@ package autumn
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -11,6 +12,7 @@ func TestNewConfig(t *testing.T) {
 		c := NewConfig()
 		So(c.tagName, ShouldEqual, "autumn")
 		So(c.leafNameMethod, ShouldEqual, "GetLeafName")
+		So(c.leafGroupsMethod, ShouldEqual, "GetLeafGroups")
 		So(c.postConstructMethod, ShouldEqual, "PostConstruct")
 		So(c.preDestroyMethod, ShouldEqual, "PreDestroy")
 	})
@@ -50,6 +52,26 @@ func TestLeafNameMethod(t *testing.T) {
 	})
 }
 
+func TestLeafGroupsMethod(t *testing.T) {
+	Convey("Sets the leaf groups method", t, func() {
+
+		c := NewConfig().LeafGroupsMethod("Test")
+		So(c.leafGroupsMethod, ShouldEqual, "Test")
+
+		Convey("Panics if the supplied method name is empty", func() {
+			So(func() {
+				NewConfig().LeafGroupsMethod("")
+			}, ShouldPanic)
+		})
+
+		Convey("Panics if the supplied method name isn't public", func() {
+			So(func() {
+				NewConfig().LeafGroupsMethod("getLeafGroups")
+			}, ShouldPanic)
+		})
+	})
+}
+
 func TestPostConstructMethod(t *testing.T) {
 	Convey("Sets the post construct method", t, func() {
 
@@ -89,3 +111,46 @@ func TestPreDestroyMethod(t *testing.T) {
 		})
 	})
 }
+
+func TestPostConstructTimeout(t *testing.T) {
+	Convey("Sets the post construct timeout", t, func() {
+
+		c := NewConfig().PostConstructTimeout(time.Second)
+		So(c.postConstructTimeout, ShouldEqual, time.Second)
+
+		Convey("Panics if the supplied timeout is negative", func() {
+			So(func() {
+				NewConfig().PostConstructTimeout(-time.Second)
+			}, ShouldPanic)
+		})
+	})
+}
+
+func TestPreDestroyTimeout(t *testing.T) {
+	Convey("Sets the pre destroy timeout", t, func() {
+
+		c := NewConfig().PreDestroyTimeout(time.Second)
+		So(c.preDestroyTimeout, ShouldEqual, time.Second)
+
+		Convey("Panics if the supplied timeout is negative", func() {
+			So(func() {
+				NewConfig().PreDestroyTimeout(-time.Second)
+			}, ShouldPanic)
+		})
+	})
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	Convey("Sets the shutdown timeout", t, func() {
+		So(NewConfig().shutdownTimeout, ShouldEqual, 30*time.Second)
+
+		c := NewConfig().ShutdownTimeout(time.Second)
+		So(c.shutdownTimeout, ShouldEqual, time.Second)
+
+		Convey("Panics if the supplied timeout is negative", func() {
+			So(func() {
+				NewConfig().ShutdownTimeout(-time.Second)
+			}, ShouldPanic)
+		})
+	})
+}
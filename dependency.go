@@ -0,0 +1,224 @@
+package autumn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	// optionalOption is the tag option that marks a dependency as optional
+	optionalOption = "optional"
+
+	// groupPrefix is the tag prefix for dependencies on a group of leaves
+	groupPrefix = "group:"
+)
+
+// dependency describes a single structure field that's injected by the tree
+type dependency struct {
+	field    string
+	index    int
+	name     string
+	group    string
+	property string
+	fallback *string
+	optional bool
+	value    reflect.Value
+	leaves   []*leaf
+}
+
+// newDependency constructs a new dependency for the supplied field from its tag, which is a leaf name followed by
+// comma-separated options. An empty leaf name means the dependency is wired by type instead of by name, a name starting
+// with "group:" injects every leaf in the group into a slice or string-keyed map, and a ${key} or ${key:default}
+// placeholder injects a property value
+func newDependency(field reflect.StructField, tag string, value reflect.Value) (*dependency, error) {
+	name, options := splitTag(tag)
+	dep := &dependency{
+		field: field.Name,
+		index: field.Index[0],
+		name:  name,
+		value: value,
+	}
+
+	if strings.HasPrefix(dep.name, groupPrefix) {
+		dep.group = strings.TrimPrefix(dep.name, groupPrefix)
+		dep.name = ""
+
+		isSlice := field.Type.Kind() == reflect.Slice
+		isMap := field.Type.Kind() == reflect.Map && field.Type.Key().Kind() == reflect.String
+		if len(dep.group) == 0 {
+			return nil, fmt.Errorf("missing group name on field %s", field.Name)
+		} else if !isSlice && !isMap {
+			return nil, fmt.Errorf("group field %s must be a slice or a map with string keys", field.Name)
+		}
+	}
+
+	if isProperty(dep.name) {
+		key, fallback, hasFallback := parseProperty(dep.name)
+		dep.property = key
+		dep.name = ""
+		if hasFallback {
+			dep.fallback = &fallback
+		}
+
+		if len(dep.property) == 0 {
+			return nil, fmt.Errorf("missing property key on field %s", field.Name)
+		} else if !isPropertyType(field.Type) {
+			return nil, fmt.Errorf("property field %s can't be of type %s", field.Name, field.Type.String())
+		}
+	}
+
+	for _, option := range options {
+		switch strings.TrimSpace(option) {
+		case optionalOption:
+			dep.optional = true
+		default:
+			return nil, fmt.Errorf("unknown option %q on field %s", option, field.Name)
+		}
+	}
+
+	return dep, nil
+}
+
+// splitTag splits a dependency tag into the leaf name and its options. A ${key:default} placeholder is read up to its
+// closing brace first, so property defaults can contain commas
+func splitTag(tag string) (string, []string) {
+	tag = strings.TrimSpace(tag)
+	if end := strings.Index(tag, "}"); strings.HasPrefix(tag, "${") && end != -1 {
+		name, rest := tag[:end+1], tag[end+1:]
+		if strings.HasPrefix(rest, ",") {
+			return name, strings.Split(rest[1:], ",")
+		} else if len(strings.TrimSpace(rest)) == 0 {
+			return name, nil
+		}
+	}
+
+	parts := strings.Split(tag, ",")
+	return strings.TrimSpace(parts[0]), parts[1:]
+}
+
+// byType determines if the dependency is wired by type
+func (d *dependency) byType() bool {
+	return len(d.name) == 0 && !d.byGroup() && !d.byProperty()
+}
+
+// byGroup determines if the dependency is wired to a group of leaves
+func (d *dependency) byGroup() bool {
+	return len(d.group) != 0
+}
+
+// byProperty determines if the dependency is wired to a property value
+func (d *dependency) byProperty() bool {
+	return len(d.property) != 0
+}
+
+// missing determines if the supplied error from find means the dependency can't be wired. Optional dependencies are
+// allowed to be missing, but not ambiguous or of the wrong type
+func (d *dependency) missing(err error) bool {
+	notFound := errors.Is(err, ErrLeafNotFound) || errors.Is(err, ErrPropertyNotFound)
+	return err != nil && !(d.optional && notFound)
+}
+
+// resolve finds all the leaves to inject for the dependency in the supplied tree
+func (d *dependency) resolve(tree *Tree) ([]*leaf, error) {
+	if d.byGroup() {
+		return d.findGroup(tree)
+	} else if d.byProperty() {
+		_, err := d.findProperty(tree)
+		return nil, err
+	}
+
+	found, err := d.find(tree)
+	if err != nil {
+		return nil, err
+	}
+	return []*leaf{found}, nil
+}
+
+// findGroup finds the members of the group to inject for the dependency in the supplied tree, making sure each one
+// fits in the collection
+func (d *dependency) findGroup(tree *Tree) ([]*leaf, error) {
+	members := tree.findGroup(d.group)
+	if len(members) == 0 {
+		return nil, fmt.Errorf("%w: no leaves in group %s exist", ErrLeafNotFound, d.group)
+	}
+
+	elementType := d.value.Type().Elem()
+	for _, member := range members {
+		if !member.pointerType().AssignableTo(elementType) {
+			return nil, errors.New("leaf " + member.name + " in group " + d.group + " of type " +
+				member.pointerType().String() + " can't be assigned to " + elementType.String())
+		}
+	}
+	return members, nil
+}
+
+// findProperty looks up the property for the dependency in the supplied tree and converts it to the field type, using
+// the default value if the property isn't set
+func (d *dependency) findProperty(tree *Tree) (reflect.Value, error) {
+	value, ok := tree.environment.Property(d.property)
+	if !ok && d.fallback == nil {
+		return reflect.Value{}, fmt.Errorf("%w: %s", ErrPropertyNotFound, d.property)
+	} else if !ok {
+		value = *d.fallback
+	}
+
+	converted, err := convertProperty(value, d.value.Type())
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("%w: %s - %v", ErrInvalidProperty, d.property, err)
+	}
+	return converted, nil
+}
+
+// inject builds the value to set in the dependency field from the supplied leaves. Single dependencies get the leaf
+// itself, while groups get a slice in the order the leaves were added, or a map keyed by leaf name
+func (d *dependency) inject(ctx context.Context, tree *Tree, leaves []*leaf) (reflect.Value, error) {
+	if d.byProperty() {
+		return d.findProperty(tree)
+	} else if !d.byGroup() {
+		return leaves[0].instance(ctx, tree)
+	}
+
+	fieldType := d.value.Type()
+	var collection reflect.Value
+	if fieldType.Kind() == reflect.Map {
+		collection = reflect.MakeMapWithSize(fieldType, len(leaves))
+	} else {
+		collection = reflect.MakeSlice(fieldType, 0, len(leaves))
+	}
+
+	for _, leaf := range leaves {
+		value, err := leaf.instance(ctx, tree)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		if fieldType.Kind() == reflect.Map {
+			collection.SetMapIndex(reflect.ValueOf(leaf.name).Convert(fieldType.Key()), value)
+		} else {
+			collection = reflect.Append(collection, value)
+		}
+	}
+	return collection, nil
+}
+
+// find finds the leaf to inject for the dependency in the supplied tree
+func (d *dependency) find(tree *Tree) (*leaf, error) {
+	if d.byType() {
+		return tree.resolveType(d.value.Type())
+	}
+
+	leaf := tree.getLeaf(d.name)
+	if leaf == nil {
+		return nil, fmt.Errorf("%w: no leaf named %s exists", ErrLeafNotFound, d.name)
+	}
+
+	// Make sure the leaf fits in the field, so interface fields get a clear error for the wrong implementation
+	if !leaf.pointerType().AssignableTo(d.value.Type()) {
+		return nil, errors.New("leaf " + d.name + " of type " + leaf.pointerType().String() + " can't be assigned to " +
+			d.value.Type().String())
+	}
+	return leaf, nil
+}
@@ -0,0 +1,171 @@
+package autumn
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type typed struct {
+	Bar   *bar   `autumn:""`
+	Other *other `autumn:""`
+	Named *bar   `autumn:"bar"`
+}
+
+type other struct{}
+
+func TestInitializeDependencies(t *testing.T) {
+	Convey("Reads the structure tags", t, func() {
+		l := mustLeaf(newLeaf(NewConfig(), &typed{}))
+		So(l.unresolvedDependencies, ShouldHaveLength, 3)
+
+		Convey("Wires empty tags by type", func() {
+			So(l.unresolvedDependencies["Bar"].byType(), ShouldBeTrue)
+		})
+
+		Convey("Wires named tags by name", func() {
+			So(l.unresolvedDependencies["Named"].byType(), ShouldBeFalse)
+			So(l.unresolvedDependencies["Named"].name, ShouldEqual, "bar")
+		})
+	})
+}
+
+func TestFindDependency(t *testing.T) {
+	Convey("Finds the leaf for a dependency", t, func() {
+		deps := mustLeaf(newLeaf(NewConfig(), &typed{})).unresolvedDependencies
+
+		Convey("Finds a leaf by type", func() {
+			b := &bar{}
+			found, err := deps["Bar"].find(NewTree().AddNamedLeaf("something", b))
+			So(err, ShouldBeNil)
+			So(found.structureValue.Interface(), ShouldEqual, b)
+		})
+
+		Convey("Finds a leaf by name", func() {
+			b := &bar{}
+			found, err := deps["Named"].find(NewTree().AddLeaf(b))
+			So(err, ShouldBeNil)
+			So(found.structureValue.Interface(), ShouldEqual, b)
+		})
+
+		Convey("Fails if there are no leaves of the type", func() {
+			_, err := deps["Other"].find(NewTree())
+			So(err.Error(), ShouldContainSubstring, "no leaf of type *autumn.other")
+		})
+
+		Convey("Fails if there are several leaves of the type, naming the candidates", func() {
+			_, err := deps["Bar"].find(NewTree().AddNamedLeaf("a", &bar{}).AddNamedLeaf("b", &bar{}))
+			So(err.Error(), ShouldContainSubstring, "a, b")
+		})
+
+		Convey("Fails if the named leaf doesn't exist", func() {
+			_, err := deps["Named"].find(NewTree())
+			So(err.Error(), ShouldContainSubstring, "no leaf named bar")
+		})
+	})
+}
+
+type store interface {
+	Store() string
+}
+
+type memoryStore struct{}
+
+func (m *memoryStore) Store() string {
+	return "memory"
+}
+
+type diskStore struct{}
+
+func (d *diskStore) Store() string {
+	return "disk"
+}
+
+type storeUser struct {
+	Store  store `autumn:""`
+	Memory store `autumn:"memory"`
+}
+
+func TestFindInterfaceDependency(t *testing.T) {
+	Convey("Finds the leaf for an interface dependency", t, func() {
+		deps := mustLeaf(newLeaf(NewConfig(), &storeUser{})).unresolvedDependencies
+
+		Convey("Finds the only implementation", func() {
+			found, err := deps["Store"].find(NewTree().AddLeaf(&diskStore{}).AddLeaf(&bar{}))
+			So(err, ShouldBeNil)
+			So(found.name, ShouldEqual, "autumn.diskStore")
+		})
+
+		Convey("Finds the primary implementation", func() {
+			tree := NewTree().AddLeaf(&memoryStore{}).AddLeaf(&diskStore{}, Primary())
+			found, err := deps["Store"].find(tree)
+			So(err, ShouldBeNil)
+			So(found.name, ShouldEqual, "autumn.diskStore")
+		})
+
+		Convey("Fails if several implementations are primary", func() {
+			tree := NewTree().AddLeaf(&memoryStore{}, Primary()).AddLeaf(&diskStore{}, Primary())
+			_, err := deps["Store"].find(tree)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Finds a qualified implementation by name", func() {
+			tree := NewTree().AddNamedLeaf("memory", &memoryStore{}).AddLeaf(&diskStore{}, Primary())
+			found, err := deps["Memory"].find(tree)
+			So(err, ShouldBeNil)
+			So(found.name, ShouldEqual, "memory")
+		})
+
+		Convey("Fails if the named leaf doesn't implement the interface", func() {
+			_, err := deps["Memory"].find(NewTree().AddNamedLeaf("memory", &bar{}))
+			So(err.Error(), ShouldContainSubstring, "can't be assigned")
+		})
+	})
+}
+
+func TestNewDependency(t *testing.T) {
+	Convey("Parses the dependency tag", t, func() {
+		field := reflect.StructField{Name: "Field", Index: []int{0}}
+
+		Convey("Reads the leaf name", func() {
+			dep, err := newDependency(field, "metrics", reflect.Value{})
+			So(err, ShouldBeNil)
+			So(dep.name, ShouldEqual, "metrics")
+			So(dep.optional, ShouldBeFalse)
+		})
+
+		Convey("Reads the optional flag", func() {
+			dep, err := newDependency(field, "metrics,optional", reflect.Value{})
+			So(err, ShouldBeNil)
+			So(dep.name, ShouldEqual, "metrics")
+			So(dep.optional, ShouldBeTrue)
+		})
+
+		Convey("Reads the optional flag for type-based dependencies", func() {
+			dep, err := newDependency(field, ",optional", reflect.Value{})
+			So(err, ShouldBeNil)
+			So(dep.byType(), ShouldBeTrue)
+			So(dep.optional, ShouldBeTrue)
+		})
+
+		Convey("Reads property defaults containing commas before the options", func() {
+			property := reflect.StructField{Name: "Hosts", Index: []int{0}, Type: reflect.TypeOf("")}
+			dep, err := newDependency(property, "${hosts:a,b}", reflect.Value{})
+			So(err, ShouldBeNil)
+			So(dep.property, ShouldEqual, "hosts")
+			So(*dep.fallback, ShouldEqual, "a,b")
+			So(dep.optional, ShouldBeFalse)
+
+			dep, err = newDependency(property, "${hosts:a,b},optional", reflect.Value{})
+			So(err, ShouldBeNil)
+			So(*dep.fallback, ShouldEqual, "a,b")
+			So(dep.optional, ShouldBeTrue)
+		})
+
+		Convey("Fails for unknown options", func() {
+			_, err := newDependency(field, "metrics,sometimes", reflect.Value{})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,192 @@
+package autumn
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// PropertySource provides property values by key
+type PropertySource interface {
+
+	// Name describes the source, such as the file it was loaded from
+	Name() string
+
+	// Property looks up a property value, returning false if the source doesn't have it
+	Property(key string) (string, bool)
+}
+
+// Precedence defines the layer a property source belongs to in an environment. Sources in a higher layer override
+// sources in a lower one
+type Precedence int
+
+const (
+	// DefaultPrecedence is for default values, which every other source overrides
+	DefaultPrecedence Precedence = iota
+
+	// FilePrecedence is for configuration files
+	FilePrecedence
+
+	// EnvironmentPrecedence is for the process environment, which is added to every environment
+	EnvironmentPrecedence
+
+	// OverridePrecedence is for explicit overrides, which override every other source
+	OverridePrecedence
+)
+
+// Environment merges property sources in precedence order: defaults, files, the process environment and then explicit
+// overrides. Within a layer, sources added later override sources added earlier. The environment of a child tree falls
+// back to its parent's for properties it doesn't have. Environments are safe for concurrent use
+type Environment struct {
+	mutex     sync.RWMutex
+	parent    *Environment
+	layers    map[Precedence][]PropertySource
+	defaults  *MapSource
+	overrides *MapSource
+}
+
+// NewEnvironment constructs a new environment containing the process environment
+func NewEnvironment() *Environment {
+	e := &Environment{
+		layers:    make(map[Precedence][]PropertySource),
+		defaults:  NewMapSource("defaults", nil),
+		overrides: NewMapSource("overrides", nil),
+	}
+	e.AddSource(DefaultPrecedence, e.defaults)
+	e.AddSource(EnvironmentPrecedence, NewEnvironmentSource())
+	e.AddSource(OverridePrecedence, e.overrides)
+	return e
+}
+
+// newChildEnvironment constructs a new environment that falls back to the supplied parent. The parent already contains
+// the process environment, so only the defaults and overrides are added
+func newChildEnvironment(parent *Environment) *Environment {
+	e := &Environment{
+		parent:    parent,
+		layers:    make(map[Precedence][]PropertySource),
+		defaults:  NewMapSource("defaults", nil),
+		overrides: NewMapSource("overrides", nil),
+	}
+	e.AddSource(DefaultPrecedence, e.defaults)
+	e.AddSource(OverridePrecedence, e.overrides)
+	return e
+}
+
+// AddSource adds a property source to the environment with the supplied precedence
+func (e *Environment) AddSource(precedence Precedence, source PropertySource) *Environment {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.layers[precedence] = append(e.layers[precedence], source)
+	return e
+}
+
+// SetDefault sets a default property value, which every other source overrides
+func (e *Environment) SetDefault(key string, value string) *Environment {
+	e.defaults.set(key, value)
+	return e
+}
+
+// SetOverride sets a property value that overrides every other source
+func (e *Environment) SetOverride(key string, value string) *Environment {
+	e.overrides.set(key, value)
+	return e
+}
+
+// Property looks up a property value, starting with the source with the highest precedence and then falling back to
+// the parent environment
+func (e *Environment) Property(key string) (string, bool) {
+	for _, source := range e.Sources() {
+		if value, ok := source.Property(key); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// Sources gets the property sources in the environment, starting with the one with the highest precedence and followed
+// by the sources in the parent environment
+func (e *Environment) Sources() []PropertySource {
+	e.mutex.RLock()
+	sources := make([]PropertySource, 0)
+	for precedence := OverridePrecedence; precedence >= DefaultPrecedence; precedence-- {
+		layer := e.layers[precedence]
+		for i := len(layer) - 1; i >= 0; i-- {
+			sources = append(sources, layer[i])
+		}
+	}
+	e.mutex.RUnlock()
+
+	if e.parent != nil {
+		sources = append(sources, e.parent.Sources()...)
+	}
+	return sources
+}
+
+// MapSource is a property source backed by a map
+type MapSource struct {
+	mutex  sync.RWMutex
+	name   string
+	values map[string]string
+}
+
+// NewMapSource constructs a new property source containing a copy of the supplied values
+func NewMapSource(name string, values map[string]string) *MapSource {
+	s := &MapSource{
+		name:   name,
+		values: make(map[string]string, len(values)),
+	}
+	for key, value := range values {
+		s.values[key] = value
+	}
+	return s
+}
+
+// Name gets the name of the source
+func (s *MapSource) Name() string {
+	return s.name
+}
+
+// Property looks up a property value
+func (s *MapSource) Property(key string) (string, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// set sets a property value
+func (s *MapSource) set(key string, value string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.values[key] = value
+}
+
+// environmentSource is a property source backed by the process environment
+type environmentSource struct{}
+
+// NewEnvironmentSource constructs a new property source that reads the process environment. A key is looked up as is,
+// and then in upper case with dots and dashes replaced by underscores, so "db.port" can be set with DB_PORT
+func NewEnvironmentSource() PropertySource {
+	return environmentSource{}
+}
+
+// Name gets the name of the source
+func (s environmentSource) Name() string {
+	return "environment"
+}
+
+// Property looks up an environment variable for the property
+func (s environmentSource) Property(key string) (string, bool) {
+	if value, ok := os.LookupEnv(key); ok {
+		return value, true
+	}
+	return os.LookupEnv(environmentKey(key))
+}
+
+// environmentKey converts a property key to the conventional environment variable name
+func environmentKey(key string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
@@ -0,0 +1,77 @@
+package autumn
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEnvironmentSource(t *testing.T) {
+	Convey("Looks up properties in the process environment", t, func() {
+		os.Setenv("AUTUMN_TEST_VALUE", "environment")
+		defer os.Unsetenv("AUTUMN_TEST_VALUE")
+
+		source := NewEnvironmentSource()
+
+		Convey("Reads the environment variable with the exact key", func() {
+			value, ok := source.Property("AUTUMN_TEST_VALUE")
+			So(ok, ShouldBeTrue)
+			So(value, ShouldEqual, "environment")
+		})
+
+		Convey("Reads the environment variable for a dotted key", func() {
+			value, ok := source.Property("autumn.test-value")
+			So(ok, ShouldBeTrue)
+			So(value, ShouldEqual, "environment")
+		})
+
+		Convey("Reports missing properties", func() {
+			_, ok := source.Property("autumn.test.missing")
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
+
+func TestEnvironment(t *testing.T) {
+	Convey("Merges property sources in precedence order", t, func() {
+		os.Setenv("AUTUMN_TEST_VALUE", "environment")
+		defer os.Unsetenv("AUTUMN_TEST_VALUE")
+
+		e := NewEnvironment().
+			SetDefault("only.default", "default").
+			SetDefault("autumn.test.value", "default").
+			AddSource(FilePrecedence, NewMapSource("first", map[string]string{"file": "first", "only.default": "file"})).
+			AddSource(FilePrecedence, NewMapSource("second", map[string]string{"file": "second"})).
+			AddSource(DefaultPrecedence, NewMapSource("more defaults", map[string]string{"file": "default"}))
+
+		Convey("Files override defaults", func() {
+			value, _ := e.Property("only.default")
+			So(value, ShouldEqual, "file")
+		})
+
+		Convey("Later sources in a layer override earlier ones", func() {
+			value, _ := e.Property("file")
+			So(value, ShouldEqual, "second")
+		})
+
+		Convey("The environment overrides files and defaults", func() {
+			value, _ := e.Property("autumn.test.value")
+			So(value, ShouldEqual, "environment")
+		})
+
+		Convey("Overrides override everything", func() {
+			e.SetOverride("autumn.test.value", "override")
+			value, _ := e.Property("autumn.test.value")
+			So(value, ShouldEqual, "override")
+		})
+
+		Convey("Lists the sources from the highest precedence", func() {
+			names := make([]string, 0)
+			for _, source := range e.Sources() {
+				names = append(names, source.Name())
+			}
+			So(names, ShouldResemble, []string{"overrides", "environment", "second", "first", "more defaults", "defaults"})
+		})
+	})
+}
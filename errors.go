@@ -0,0 +1,110 @@
+package autumn
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalidLeaf is returned when a leaf, provider or lifecycle method doesn't have the expected type or signature
+	ErrInvalidLeaf = errors.New("invalid leaf")
+
+	// ErrDuplicateName is returned when a leaf or alias name is already in use
+	ErrDuplicateName = errors.New("duplicate leaf name")
+
+	// ErrLeafNotFound is returned when a named leaf doesn't exist in the tree
+	ErrLeafNotFound = errors.New("leaf not found")
+
+	// ErrWrongType is returned when a leaf is retrieved or provided as a type it doesn't have
+	ErrWrongType = errors.New("leaf has the wrong type")
+
+	// ErrLeafInUse is returned when removing a leaf that other leaves depend on
+	ErrLeafInUse = errors.New("leaf in use")
+
+	// ErrPropertyNotFound is returned when a property without a default value isn't set
+	ErrPropertyNotFound = errors.New("property not found")
+
+	// ErrInvalidProperty is returned when a property value can't be converted to the type of its field
+	ErrInvalidProperty = errors.New("invalid property")
+
+	// ErrUnresolvedDependencies is returned when growing a tree with dependencies that can't be wired
+	ErrUnresolvedDependencies = errors.New("failed to wire dependencies")
+
+	// ErrConstruction is returned when a leaf can't be constructed by its provider
+	ErrConstruction = errors.New("failed to construct leaf")
+
+	// ErrLifecycle is returned when a PostConstruct or PreDestroy function fails
+	ErrLifecycle = errors.New("lifecycle method failed")
+
+	// ErrNotConstructed is returned when a leaf's value is looked up before its provider has been called by growing the
+	// tree
+	ErrNotConstructed = errors.New("leaf not constructed")
+
+	// ErrReentrant is returned when a lifecycle function grows, chops, refreshes or removes leaves from the tree that's
+	// calling it, which would otherwise never return
+	ErrReentrant = errors.New("tree lifecycle called re-entrantly")
+)
+
+// Errors combines several errors into one, such as the PreDestroy failures collected while chopping a tree
+type Errors []error
+
+// Error joins the messages of the combined errors
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Is determines if any of the combined errors matches the target, for use with errors.Is
+func (e Errors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the combined errors that matches the target, for use with errors.As
+func (e Errors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// combineErrors combines the supplied errors, returning nil if there are none and the error itself if there's one
+func combineErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return Errors(errs)
+}
+
+// PanicError describes a panic recovered from a provider, lifecycle method, or leaf name or group method, keeping the
+// stack trace of the goroutine that panicked
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error describes the panic, leaving the stack trace out so messages stay readable
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap gets the panic value if it's an error, so errors.Is sees it
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package autumn
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestErrors(t *testing.T) {
+	Convey("Combines several errors", t, func() {
+		first := errors.New("first")
+		err := combineErrors([]error{first, ErrLifecycle})
+
+		Convey("Joins the error messages", func() {
+			So(err.Error(), ShouldEqual, "first\n"+ErrLifecycle.Error())
+		})
+
+		Convey("Matches any of the errors", func() {
+			So(errors.Is(err, first), ShouldBeTrue)
+			So(errors.Is(err, ErrLifecycle), ShouldBeTrue)
+			So(errors.Is(err, ErrLeafNotFound), ShouldBeFalse)
+		})
+
+		Convey("Returns nil if there are no errors", func() {
+			So(combineErrors(nil), ShouldBeNil)
+		})
+
+		Convey("Returns a single error as is", func() {
+			So(combineErrors([]error{first}), ShouldEqual, first)
+		})
+	})
+}
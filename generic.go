@@ -0,0 +1,91 @@
+package autumn
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Get gets the value of the named leaf as a T, which may be the leaf's structure pointer type or an interface it
+// implements. Prototype leaves return a new, wired instance. Returns ErrLeafNotFound if the leaf doesn't exist and
+// ErrWrongType if its value isn't a T
+func Get[T any](tree *Tree, name string) (T, error) {
+	leaf := tree.GetLeaf(name)
+	if leaf == nil {
+		var zero T
+		return zero, fmt.Errorf("%w: no leaf named %s exists", ErrLeafNotFound, name)
+	}
+	return instanceOf[T](tree, leaf)
+}
+
+// MustGet gets the value of the named leaf as a T like Get, panicking if it can't
+func MustGet[T any](tree *Tree, name string) T {
+	value, err := Get[T](tree, name)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// GetByType gets the value of the single leaf that's a T, using the primary leaf if several are. Returns
+// ErrLeafNotFound if no leaves are a T
+func GetByType[T any](tree *Tree) (T, error) {
+	tree.mutex.RLock()
+	leaf, err := tree.resolveType(typeOf[T]())
+	tree.mutex.RUnlock()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return instanceOf[T](tree, leaf)
+}
+
+// MustGetByType gets the value of the single leaf that's a T like GetByType, panicking if it can't
+func MustGetByType[T any](tree *Tree) T {
+	value, err := GetByType[T](tree)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// Provide adds a leaf that's constructed by the supplied function like Tree.AddProvider, making sure the function
+// returns a *T. Panics if the function is invalid, returns something else or the leaf name is taken
+func Provide[T any](tree *Tree, function interface{}, options ...LeafOption) *Tree {
+	return tree.must(ProvideE[T](tree, function, options...))
+}
+
+// ProvideE adds a leaf that's constructed by the supplied function like Provide, returning an error instead of
+// panicking
+func ProvideE[T any](tree *Tree, function interface{}, options ...LeafOption) error {
+	leaf, err := newProviderLeaf(tree.configuration(), function)
+	if err != nil {
+		return err
+	}
+
+	expected := typeOf[*T]()
+	if leaf.pointerType() != expected {
+		return fmt.Errorf("%w: provider returns %s instead of %s", ErrWrongType, leaf.pointerType().String(),
+			expected.String())
+	}
+	return tree.add(leaf, options...)
+}
+
+// instanceOf gets the value of the supplied leaf as a T. The tree mustn't be locked
+func instanceOf[T any](tree *Tree, leaf *leaf) (T, error) {
+	var zero T
+	if !leaf.pointerType().AssignableTo(typeOf[T]()) {
+		return zero, fmt.Errorf("%w: leaf %s of type %s can't be used as %s", ErrWrongType, leaf.name,
+			leaf.pointerType().String(), typeOf[T]().String())
+	}
+
+	value, err := tree.value(leaf)
+	if err != nil {
+		return zero, err
+	}
+	return value.Interface().(T), nil
+}
+
+// typeOf gets the reflection type of T, which works for interfaces as well as concrete types
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
@@ -0,0 +1,66 @@
+package autumn
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGet(t *testing.T) {
+	Convey("Gets typed leaf values", t, func() {
+		memory := &memoryStore{}
+		tree := NewTree().AddNamedLeaf("memory", memory).AddNamedLeaf("child", &child{}, Prototype()).Grow()
+
+		Convey("Gets a leaf as its structure pointer or an interface", func() {
+			value, err := Get[*memoryStore](tree, "memory")
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, memory)
+
+			s, err := Get[store](tree, "memory")
+			So(err, ShouldBeNil)
+			So(s.Store(), ShouldEqual, "memory")
+		})
+
+		Convey("Creates a new prototype instance", func() {
+			first := MustGet[*child](tree, "child")
+			So(first.pcValue, ShouldEqual, 1)
+			So(MustGet[*child](tree, "child"), ShouldNotEqual, first)
+		})
+
+		Convey("Fails for missing leaves and the wrong type", func() {
+			_, err := Get[*memoryStore](tree, "missing")
+			So(errors.Is(err, ErrLeafNotFound), ShouldBeTrue)
+
+			_, err = Get[*diskStore](tree, "memory")
+			So(errors.Is(err, ErrWrongType), ShouldBeTrue)
+			So(err.Error(), ShouldEqual, "leaf has the wrong type: leaf memory of type *autumn.memoryStore can't be used as *autumn.diskStore")
+
+			So(func() { MustGet[*diskStore](tree, "memory") }, ShouldPanic)
+		})
+
+		Convey("Gets a leaf by type", func() {
+			So(MustGetByType[store](tree), ShouldEqual, memory)
+
+			_, err := GetByType[*diskStore](tree)
+			So(errors.Is(err, ErrLeafNotFound), ShouldBeTrue)
+			So(func() { MustGetByType[*diskStore](tree) }, ShouldPanic)
+		})
+	})
+}
+
+func TestProvide(t *testing.T) {
+	Convey("Adds typed providers", t, func() {
+
+		Convey("Adds a provider that returns the type", func() {
+			tree := Provide[diskStore](NewTree(), func() *diskStore { return &diskStore{} }).Grow()
+			So(MustGetByType[*diskStore](tree).Store(), ShouldEqual, "disk")
+		})
+
+		Convey("Fails if the provider returns another type", func() {
+			err := ProvideE[diskStore](NewTree(), func() *memoryStore { return &memoryStore{} })
+			So(errors.Is(err, ErrWrongType), ShouldBeTrue)
+			So(func() { Provide[diskStore](NewTree(), func() *memoryStore { return nil }) }, ShouldPanic)
+		})
+	})
+}
@@ -0,0 +1,232 @@
+package autumn
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Graph describes the leaves in a tree and the dependencies between them
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode describes a leaf in a graph
+type GraphNode struct {
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`
+	Scope         string   `json:"scope"`
+	Aliases       []string `json:"aliases,omitempty"`
+	Groups        []string `json:"groups,omitempty"`
+	Provider      bool     `json:"provider,omitempty"`
+	PostConstruct string   `json:"postConstruct,omitempty"`
+	PreDestroy    string   `json:"preDestroy,omitempty"`
+	Parent        bool     `json:"parent,omitempty"`
+}
+
+// GraphEdge describes a dependency of one leaf on another. The field is the name of the dependent leaf's field, or
+// "parameter <n>" for a provider parameter
+type GraphEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Field    string `json:"field"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+// Graph describes the leaves in the tree and the dependencies between them, in the order the leaves were added. Leaves
+// from the parent tree are included when this tree's leaves depend on them. Dependencies that haven't been wired yet,
+// such as those of prototypes or of a tree that hasn't been grown, are resolved without being injected and left out
+// if they can't be found
+func (t *Tree) Graph() *Graph {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	graph := &Graph{Nodes: make([]GraphNode, 0), Edges: make([]GraphEdge, 0)}
+	added := make(map[*leaf]bool)
+
+	addNode := func(leaf *leaf) {
+		if !added[leaf] {
+			added[leaf] = true
+			graph.Nodes = append(graph.Nodes, t.graphNode(leaf))
+		}
+	}
+
+	targets := make([]*leaf, 0)
+	for _, leafName := range t.addedLeaves {
+		leaf := t.leaves[leafName]
+		addNode(leaf)
+
+		for _, edge := range leaf.graphEdges(t) {
+			targets = append(targets, edge.to)
+			graph.Edges = append(graph.Edges, GraphEdge{
+				From:     leaf.name,
+				To:       edge.to.name,
+				Field:    edge.field,
+				Optional: edge.optional,
+			})
+		}
+	}
+
+	// Add the parent leaves that were depended on after the tree's own leaves
+	for _, target := range targets {
+		addNode(target)
+	}
+
+	return graph
+}
+
+// graphNode describes the supplied leaf as a graph node
+func (t *Tree) graphNode(leaf *leaf) GraphNode {
+	node := GraphNode{
+		Name:     leaf.name,
+		Type:     leaf.pointerType().String(),
+		Scope:    leaf.scope.String(),
+		Aliases:  t.aliases(leaf),
+		Groups:   append([]string(nil), leaf.groups...),
+		Provider: leaf.provider != nil,
+		Parent:   t.owner(leaf) != t,
+	}
+	if leaf.postConstruct != nil {
+		node.PostConstruct = leaf.postConstruct.name
+	}
+	if leaf.preDestroy != nil {
+		node.PreDestroy = leaf.preDestroy.name
+	}
+	return node
+}
+
+// graphEdge describes a dependency of a leaf on another leaf
+type graphEdge struct {
+	to       *leaf
+	field    string
+	optional bool
+}
+
+// graphEdges describes the leaf's dependencies on other leaves, starting with the provider parameters and followed by
+// the fields in declaration order. Dependencies that haven't been wired are resolved in the supplied tree
+func (l *leaf) graphEdges(tree *Tree) []graphEdge {
+	edges := make([]graphEdge, 0)
+
+	if l.provider != nil {
+		for i, parameter := range l.provider.parameters {
+			to, err := l.providerArgument(tree, i, parameter)
+			if err == nil {
+				edges = append(edges, graphEdge{to: to, field: fmt.Sprintf("parameter %d", i)})
+			}
+		}
+	}
+
+	for _, dep := range l.allDependencies() {
+		leaves := dep.leaves
+		if _, resolved := l.resolvedDependencies[dep.field]; !resolved {
+			leaves, _ = dep.resolve(tree)
+		}
+		for _, to := range leaves {
+			edges = append(edges, graphEdge{to: to, field: dep.field, optional: dep.optional})
+		}
+	}
+	return edges
+}
+
+// providerArgument finds the leaf for a provider parameter, using the leaf it was called with if it's been constructed
+func (l *leaf) providerArgument(tree *Tree, index int, parameter reflect.Type) (*leaf, error) {
+	if l.structureValue.IsValid() {
+		return l.provider.arguments[index], nil
+	}
+	return tree.resolveType(parameter)
+}
+
+// DOT renders the graph in the Graphviz DOT language. Prototypes are drawn with dashed borders and leaves from the
+// parent tree in grey
+func (g *Graph) DOT() string {
+	builder := &strings.Builder{}
+	builder.WriteString("digraph autumn {\n")
+	builder.WriteString("    node [shape=box];\n")
+
+	for _, node := range g.Nodes {
+		attributes := []string{"label=" + dotQuote(strings.Join(node.labelLines(), "\n"))}
+		if node.Scope == "prototype" {
+			attributes = append(attributes, "style=dashed")
+		}
+		if node.Parent {
+			attributes = append(attributes, "color=grey", "fontcolor=grey")
+		}
+		fmt.Fprintf(builder, "    %s [%s];\n", dotQuote(node.Name), strings.Join(attributes, ", "))
+	}
+
+	for _, edge := range g.Edges {
+		attributes := []string{"label=" + dotQuote(edge.Field)}
+		if edge.Optional {
+			attributes = append(attributes, "style=dashed")
+		}
+		fmt.Fprintf(builder, "    %s -> %s [%s];\n", dotQuote(edge.From), dotQuote(edge.To),
+			strings.Join(attributes, ", "))
+	}
+
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart. Prototypes are drawn with rounded ends and optional dependencies
+// with dotted arrows
+func (g *Graph) Mermaid() string {
+	ids := make(map[string]string, len(g.Nodes))
+	builder := &strings.Builder{}
+	builder.WriteString("flowchart LR\n")
+
+	for i, node := range g.Nodes {
+		ids[node.Name] = fmt.Sprintf("leaf%d", i)
+		label := mermaidQuote(strings.Join(node.labelLines(), "<br/>"))
+		if node.Scope == "prototype" {
+			fmt.Fprintf(builder, "    %s([%s])\n", ids[node.Name], label)
+		} else {
+			fmt.Fprintf(builder, "    %s[%s]\n", ids[node.Name], label)
+		}
+	}
+
+	for _, edge := range g.Edges {
+		arrow := "-->"
+		if edge.Optional {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(builder, "    %s %s|%s| %s\n", ids[edge.From], arrow, mermaidQuote(edge.Field), ids[edge.To])
+	}
+
+	return builder.String()
+}
+
+// JSON renders the graph as indented JSON
+func (g *Graph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g, "", "  ")
+}
+
+// labelLines describes the node for the DOT and Mermaid renderers: its name, its type and its lifecycle methods
+func (n GraphNode) labelLines() []string {
+	lines := []string{n.Name, n.Type}
+	if len(n.Aliases) != 0 {
+		lines = append(lines, "aka "+strings.Join(n.Aliases, ", "))
+	}
+
+	methods := make([]string, 0, 2)
+	for _, method := range []string{n.PostConstruct, n.PreDestroy} {
+		if method != "" {
+			methods = append(methods, method)
+		}
+	}
+	if len(methods) != 0 {
+		lines = append(lines, strings.Join(methods, ", "))
+	}
+	return lines
+}
+
+// dotQuote quotes a DOT identifier or label
+func dotQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+// mermaidQuote quotes a Mermaid label, escaping the quotes inside it
+func mermaidQuote(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, "#quot;") + `"`
+}
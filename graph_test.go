@@ -0,0 +1,94 @@
+package autumn
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type graphService struct {
+	Store  store        `autumn:""`
+	Child  *child       `autumn:"child,optional"`
+	Worker *graphWorker `autumn:"worker"`
+	Port   int          `autumn:"${port:80}"`
+}
+
+type graphWorker struct {
+	Store store `autumn:""`
+}
+
+func TestGraph(t *testing.T) {
+	Convey("Describes the tree as a graph", t, func() {
+		tree := NewTree().
+			AddNamedLeaf("service", &graphService{}).
+			AddProvider(func(c *child) *memoryStore { return &memoryStore{} }, Groups("stores")).
+			AddLeaf(&child{}).
+			AddAlias("child", "kid").
+			AddNamedLeaf("worker", &graphWorker{}, Prototype()).
+			Grow()
+		graph := tree.Graph()
+
+		Convey("Lists the leaves in the order they were added", func() {
+			So(graph.Nodes, ShouldResemble, []GraphNode{
+				{Name: "service", Type: "*autumn.graphService", Scope: "singleton"},
+				{Name: "autumn.memoryStore", Type: "*autumn.memoryStore", Scope: "singleton", Groups: []string{"stores"}, Provider: true},
+				{Name: "child", Type: "*autumn.child", Scope: "singleton", Aliases: []string{"kid"}, PostConstruct: "PostConstruct", PreDestroy: "PreDestroy"},
+				{Name: "worker", Type: "*autumn.graphWorker", Scope: "prototype"},
+			})
+		})
+
+		Convey("Copies the groups, so the graph can't change the leaves", func() {
+			graph.Nodes[1].Groups[0] = "changed"
+			So(tree.Graph().Nodes[1].Groups, ShouldResemble, []string{"stores"})
+		})
+
+		Convey("Lists the dependencies, including unwired prototype dependencies", func() {
+			So(graph.Edges, ShouldResemble, []GraphEdge{
+				{From: "service", To: "autumn.memoryStore", Field: "Store"},
+				{From: "service", To: "child", Field: "Child", Optional: true},
+				{From: "service", To: "worker", Field: "Worker"},
+				{From: "autumn.memoryStore", To: "child", Field: "parameter 0"},
+				{From: "worker", To: "autumn.memoryStore", Field: "Store"},
+			})
+		})
+
+		Convey("Includes the parent leaves that are depended on", func() {
+			graph := tree.NewChild().AddLeaf(&parent{}).Grow().Graph()
+
+			So(graph.Nodes, ShouldHaveLength, 2)
+			So(graph.Nodes[1].Name, ShouldEqual, "child")
+			So(graph.Nodes[1].Parent, ShouldBeTrue)
+			So(graph.Edges, ShouldResemble, []GraphEdge{{From: "autumn.parent", To: "child", Field: "C"}})
+		})
+
+		Convey("Renders DOT", func() {
+			dot := NewTree().AddLeaf(&parent{}).AddLeaf(&child{}, Prototype()).Graph().DOT()
+			So(dot, ShouldEqual, `digraph autumn {
+    node [shape=box];
+    "autumn.parent" [label="autumn.parent\n*autumn.parent\nPostConstruct"];
+    "child" [label="child\n*autumn.child\nPostConstruct, PreDestroy", style=dashed];
+    "autumn.parent" -> "child" [label="C"];
+}
+`)
+		})
+
+		Convey("Renders Mermaid", func() {
+			mermaid := NewTree().AddLeaf(&parent{}).AddLeaf(&child{}, Prototype()).Graph().Mermaid()
+			So(mermaid, ShouldEqual, `flowchart LR
+    leaf0["autumn.parent<br/>*autumn.parent<br/>PostConstruct"]
+    leaf1(["child<br/>*autumn.child<br/>PostConstruct, PreDestroy"])
+    leaf0 -->|"C"| leaf1
+`)
+		})
+
+		Convey("Renders JSON", func() {
+			data, err := graph.JSON()
+			So(err, ShouldBeNil)
+
+			decoded := &Graph{}
+			So(json.Unmarshal(data, decoded), ShouldBeNil)
+			So(decoded, ShouldResemble, graph)
+		})
+	})
+}
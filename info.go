@@ -0,0 +1,131 @@
+package autumn
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// LeafState describes how far a leaf has got through growing and chopping its tree
+type LeafState int
+
+const (
+	// LeafRegistered leaves have been added to the tree but not wired yet
+	LeafRegistered LeafState = iota
+
+	// LeafWired leaves have been constructed and had their dependencies set, but haven't been post-constructed
+	LeafWired
+
+	// LeafReady leaves have been post-constructed. Prototypes are ready once their dependencies have been checked
+	LeafReady
+
+	// LeafDestroyed leaves have been pre-destroyed
+	LeafDestroyed
+)
+
+// String describes the leaf state
+func (s LeafState) String() string {
+	switch s {
+	case LeafRegistered:
+		return "registered"
+	case LeafWired:
+		return "wired"
+	case LeafReady:
+		return "ready"
+	case LeafDestroyed:
+		return "destroyed"
+	}
+	return fmt.Sprintf("LeafState(%d)", int(s))
+}
+
+// LeafInfo is a read-only description of a leaf in a tree
+type LeafInfo struct {
+	Name             string
+	Aliases          []string
+	Type             reflect.Type
+	Scope            string
+	Groups           []string
+	Primary          bool
+	Provider         bool
+	HasPostConstruct bool
+	HasPreDestroy    bool
+	State            LeafState
+	Dependencies     []DependencyInfo
+
+	// Value is the structure pointer for singleton leaves that have been constructed, and nil otherwise
+	Value interface{}
+}
+
+// DependencyInfo describes a dependency field of a leaf
+type DependencyInfo struct {
+	Field    string
+	Leaves   []string
+	Property string
+	Optional bool
+	Resolved bool
+}
+
+// Leaves describes the leaves that were added to the tree, in the order they were added. Leaves in the parent tree and
+// conditional leaves that haven't been added yet aren't included
+func (t *Tree) Leaves() []LeafInfo {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	leaves := make([]LeafInfo, 0, len(t.addedLeaves))
+	for _, leafName := range t.addedLeaves {
+		leaves = append(leaves, t.describe(t.leaves[leafName]))
+	}
+	return leaves
+}
+
+// Describe describes the leaf with the supplied name or alias, falling back to the parent tree like GetLeaf. Returns nil
+// if the leaf doesn't exist
+func (t *Tree) Describe(name string) *LeafInfo {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	leaf := t.getLeaf(name)
+	if leaf == nil {
+		return nil
+	}
+
+	info := t.describe(leaf)
+	return &info
+}
+
+// describe builds the read-only description of the supplied leaf. The tree must be locked
+func (t *Tree) describe(leaf *leaf) LeafInfo {
+	info := LeafInfo{
+		Name:             leaf.name,
+		Aliases:          t.aliases(leaf),
+		Type:             leaf.pointerType(),
+		Scope:            leaf.scope.String(),
+		Groups:           append([]string(nil), leaf.groups...),
+		Primary:          leaf.primary,
+		Provider:         leaf.provider != nil,
+		HasPostConstruct: leaf.postConstruct != nil,
+		HasPreDestroy:    leaf.preDestroy != nil,
+		State:            leaf.state,
+		Dependencies:     make([]DependencyInfo, 0),
+	}
+	if leaf.scope != prototype && leaf.structureValue.IsValid() {
+		info.Value = leaf.structureValue.Interface()
+	}
+
+	for _, dep := range leaf.allDependencies() {
+		_, resolved := leaf.resolvedDependencies[dep.field]
+		dependency := DependencyInfo{
+			Field:    dep.field,
+			Leaves:   make([]string, 0, len(dep.leaves)),
+			Property: dep.property,
+			Optional: dep.optional,
+			Resolved: resolved,
+		}
+		if resolved {
+			for _, l := range dep.leaves {
+				dependency.Leaves = append(dependency.Leaves, l.name)
+			}
+		}
+		info.Dependencies = append(info.Dependencies, dependency)
+	}
+	return info
+}
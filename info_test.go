@@ -0,0 +1,82 @@
+package autumn
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDescribe(t *testing.T) {
+	Convey("Describes leaves", t, func() {
+		service := &graphService{}
+		tree := NewTree().
+			AddNamedLeaf("service", service).
+			AddNamedLeaf("memory", &memoryStore{}, Primary(), Groups("stores")).
+			AddLeaf(&child{}).
+			AddAlias("child", "kid").
+			AddNamedLeaf("worker", &graphWorker{}, Prototype())
+
+		Convey("Lists the leaves in the order they were added", func() {
+			names := make([]string, 0)
+			for _, info := range tree.Leaves() {
+				names = append(names, info.Name)
+			}
+			So(names, ShouldResemble, []string{"service", "memory", "child", "worker"})
+		})
+
+		Convey("Describes a leaf by name or alias", func() {
+			tree.Grow()
+
+			So(tree.Describe("kid"), ShouldResemble, &LeafInfo{
+				Name:             "child",
+				Aliases:          []string{"kid"},
+				Type:             reflect.TypeOf(&child{}),
+				Scope:            "singleton",
+				HasPostConstruct: true,
+				HasPreDestroy:    true,
+				State:            LeafReady,
+				Dependencies:     []DependencyInfo{},
+				Value:            tree.GetLeafValue("child"),
+			})
+			So(tree.Describe("memory").Primary, ShouldBeTrue)
+			So(tree.Describe("memory").Groups, ShouldResemble, []string{"stores"})
+			So(tree.Describe("missing"), ShouldBeNil)
+		})
+
+		Convey("Describes the dependencies", func() {
+			tree.Grow()
+
+			So(tree.Describe("service").Dependencies, ShouldResemble, []DependencyInfo{
+				{Field: "Store", Leaves: []string{"memory"}, Resolved: true},
+				{Field: "Child", Leaves: []string{"child"}, Optional: true, Resolved: true},
+				{Field: "Worker", Leaves: []string{"worker"}, Resolved: true},
+				{Field: "Port", Leaves: []string{}, Property: "port", Resolved: true},
+			})
+			So(tree.Describe("worker").Dependencies, ShouldResemble, []DependencyInfo{
+				{Field: "Store", Leaves: []string{}},
+			})
+		})
+
+		Convey("Tracks the leaf state", func() {
+			So(tree.Describe("service").State, ShouldEqual, LeafRegistered)
+			So(tree.Describe("service").Value, ShouldEqual, service)
+
+			tree.Grow()
+			So(tree.Describe("service").State, ShouldEqual, LeafReady)
+			So(tree.Describe("worker").State, ShouldEqual, LeafReady)
+			So(tree.Describe("worker").Value, ShouldBeNil)
+
+			tree.Chop()
+			So(tree.Describe("service").State, ShouldEqual, LeafDestroyed)
+			So(tree.Describe("service").State.String(), ShouldEqual, "destroyed")
+		})
+
+		Convey("Leaves a leaf wired if PostConstruct fails", func() {
+			tree := NewTree().AddNamedLeaf("failing", &failingLifecycle{Err: errors.New("failed")})
+			So(tree.GrowE(), ShouldNotBeNil)
+			So(tree.Describe("failing").State, ShouldEqual, LeafWired)
+		})
+	})
+}
@@ -1,41 +1,89 @@
 package autumn
 
 import (
+	"context"
+	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
 )
 
+// scope describes how instances of a leaf are created
+type scope int
+
+const (
+	// singleton leaves share a single instance
+	singleton scope = iota
+
+	// prototype leaves create a new instance for every injection point and retrieval
+	prototype
+)
+
+// String describes the scope
+func (s scope) String() string {
+	if s == prototype {
+		return "prototype"
+	}
+	return "singleton"
+}
+
+// creatingKey is the context key for the set of prototype leaves being created
+type creatingKey struct{}
+
+// injectingKey is the context key for the leaf being wired while the tree is grown, which holds on to the prototype
+// instances it's injected with until it's post-constructed
+type injectingKey struct{}
+
 // leaf describes a single injected class
 type leaf struct {
 	structureType    reflect.Type
 	structureValue   reflect.Value
 	structureElement reflect.Value
 
+	// registered is a copy of the structure as it was registered, which leaves added with RecreateOnRefresh start over
+	// from when the tree is refreshed. Other leaves don't keep one
+	registered reflect.Value
+
 	name          string
-	postConstruct reflect.Value
-	preDestroy    reflect.Value
+	groups        []string
+	conditions    []condition
+	state         LeafState
+	scope         scope
+	primary       bool
+	keep          bool
+	recreate      bool
+	instances     []*leaf
+	provider      *provider
+	postConstruct *lifecycleMethod
+	preDestroy    *lifecycleMethod
+
+	postConstructTimeout time.Duration
+	preDestroyTimeout    time.Duration
 
-	unresolvedDependencies map[string]reflect.Value
-	resolvedDependencies   map[string]reflect.Value
+	unresolvedDependencies map[string]*dependency
+	resolvedDependencies   map[string]*dependency
 }
 
 // newLeaf constructs a new leaf, using the structure name as the name
-func newLeaf(config *config, structurePointer interface{}) *leaf {
+func newLeaf(config *config, structurePointer interface{}) (*leaf, error) {
 	leaf := &leaf{
 		structureType:    getStructureType(structurePointer),
 		structureValue:   getStructureValue(structurePointer),
 		structureElement: getStructureElement(structurePointer),
 	}
 
-	leaf.initializeName(config.leafNameMethod)
-	leaf.initializeDependencies(config.tagName)
-	leaf.initializePostConstruct(config.postConstructMethod)
-	leaf.initializePreDestroy(config.preDestroyMethod)
-
-	return leaf
+	if err := leaf.initializeName(config.leafNameMethod); err != nil {
+		return nil, err
+	}
+	if err := leaf.initializeGroups(config.leafGroupsMethod); err != nil {
+		return nil, err
+	}
+	return leaf, leaf.initialize(config)
 }
 
 // newNamedLeaf constructs a new leaf with the specified name
-func newNamedLeaf(config *config, name string, structurePointer interface{}) *leaf {
+func newNamedLeaf(config *config, name string, structurePointer interface{}) (*leaf, error) {
 	leaf := &leaf{
 		structureType:    getStructureType(structurePointer),
 		structureValue:   getStructureValue(structurePointer),
@@ -43,114 +91,508 @@ func newNamedLeaf(config *config, name string, structurePointer interface{}) *le
 		name:             name,
 	}
 
-	leaf.initializeDependencies(config.tagName)
-	leaf.initializePostConstruct(config.postConstructMethod)
-	leaf.initializePreDestroy(config.preDestroyMethod)
+	if err := leaf.initializeGroups(config.leafGroupsMethod); err != nil {
+		return nil, err
+	}
+	return leaf, leaf.initialize(config)
+}
+
+// newProviderLeaf constructs a new leaf that's built by the supplied provider function, using the structure name as
+// the name
+func newProviderLeaf(config *config, function interface{}) (*leaf, error) {
+	provider, err := newProvider(function)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf := &leaf{
+		structureType: provider.structureType(),
+		provider:      provider,
+	}
+
+	if err := leaf.initializeName(config.leafNameMethod); err != nil {
+		return nil, err
+	}
+	return leaf, leaf.initializeGroups(config.leafGroupsMethod)
+}
+
+// newNamedProviderLeaf constructs a new leaf that's built by the supplied provider function, with the specified name
+func newNamedProviderLeaf(config *config, name string, function interface{}) (*leaf, error) {
+	provider, err := newProvider(function)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf := &leaf{
+		structureType: provider.structureType(),
+		provider:      provider,
+		name:          name,
+	}
 
-	return leaf
+	return leaf, leaf.initializeGroups(config.leafGroupsMethod)
+}
+
+// initialize reads the dependencies and lifecycle methods from the leaf structure
+func (l *leaf) initialize(config *config) error {
+	if err := l.initializeDependencies(config.tagName); err != nil {
+		return err
+	}
+	if err := l.initializePostConstruct(config.postConstructMethod); err != nil {
+		return err
+	}
+	return l.initializePreDestroy(config.preDestroyMethod)
 }
 
 // initializeName initializes the name for the leaf
-func (l *leaf) initializeName(getNameMethod string) {
+func (l *leaf) initializeName(getNameMethod string) error {
+
+	// Provider leaves haven't been constructed yet, so ask a zero value of the structure for the name
+	value := l.structureValue
+	if !value.IsValid() {
+		value = reflect.New(l.structureType)
+	}
 
-	method := l.structureValue.MethodByName(getNameMethod)
+	method := value.MethodByName(getNameMethod)
 	if !method.IsValid() {
 		l.name = l.structureType.String()
-		return
+		return nil
 	}
 
 	if method.Type().NumIn() != 0 {
-		panic(l.structureType.String() + " - " + getNameMethod + " must not take any parameters")
+		return l.invalid(getNameMethod + " must not take any parameters")
 	} else if method.Type().NumOut() != 1 {
-		panic(l.structureType.String() + " - " + getNameMethod + " must return exactly one parameter")
+		return l.invalid(getNameMethod + " must return exactly one parameter")
 	} else if method.Type().Out(0).Kind() != reflect.String {
-		panic(l.structureType.String() + " - " + getNameMethod + " must return a string")
+		return l.invalid(getNameMethod + " must return a string")
+	}
+
+	results, err := callFunction(method, []reflect.Value{})
+	if err != nil {
+		return fmt.Errorf("%w: %s - %s failed: %w", ErrInvalidLeaf, l.structureType.String(), getNameMethod, err)
+	}
+
+	l.name = results[0].String()
+	return nil
+}
+
+// initializeGroups initializes the groups the leaf belongs to, using the groups method if the structure has one
+func (l *leaf) initializeGroups(getGroupsMethod string) error {
+
+	// Provider leaves haven't been constructed yet, so ask a zero value of the structure for the groups
+	value := l.structureValue
+	if !value.IsValid() {
+		value = reflect.New(l.structureType)
+	}
+
+	method := value.MethodByName(getGroupsMethod)
+	if !method.IsValid() {
+		return nil
+	}
+
+	if method.Type().NumIn() != 0 {
+		return l.invalid(getGroupsMethod + " must not take any parameters")
+	} else if method.Type().NumOut() != 1 || method.Type().Out(0) != reflect.TypeOf([]string{}) {
+		return l.invalid(getGroupsMethod + " must return a string slice")
+	}
+
+	results, err := callFunction(method, []reflect.Value{})
+	if err != nil {
+		return fmt.Errorf("%w: %s - %s failed: %w", ErrInvalidLeaf, l.structureType.String(), getGroupsMethod, err)
+	}
+
+	l.joinGroups(results[0].Interface().([]string)...)
+	return nil
+}
+
+// joinGroups adds the leaf to the supplied groups, ignoring the ones it's already in
+func (l *leaf) joinGroups(groups ...string) {
+	for _, group := range groups {
+		if !l.inGroup(group) {
+			l.groups = append(l.groups, group)
+		}
 	}
+}
 
-	l.name = method.Call([]reflect.Value{})[0].String()
+// inGroup determines if the leaf belongs to the supplied group
+func (l *leaf) inGroup(group string) bool {
+	for _, g := range l.groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
 }
 
-// initializeDependencies reads in structure tags to find dependencies
-func (l *leaf) initializeDependencies(tagName string) {
-	l.unresolvedDependencies = map[string]reflect.Value{}
-	l.resolvedDependencies = map[string]reflect.Value{}
+// initializeDependencies reads in structure tags to find dependencies. Fields with an empty tag are wired by type,
+// and dependencies are keyed by field name
+func (l *leaf) initializeDependencies(tagName string) error {
+	l.unresolvedDependencies = map[string]*dependency{}
+	l.resolvedDependencies = map[string]*dependency{}
 
 	for i := 0; i < l.structureType.NumField(); i++ {
 		field := l.structureType.Field(i)
-		dep := field.Tag.Get(tagName)
-		if len(dep) != 0 {
-			l.unresolvedDependencies[dep] = l.structureElement.FieldByName(field.Name)
+		tag, ok := field.Tag.Lookup(tagName)
+		if !ok {
+			continue
+		}
+
+		dep, err := newDependency(field, tag, l.structureElement.Field(i))
+		if err != nil {
+			return l.invalid(err.Error())
+		}
+		l.unresolvedDependencies[field.Name] = dep
+	}
+	return nil
+}
+
+// initializePostConstruct initializes the post construct function for the leaf, returning an error if it's invalid
+func (l *leaf) initializePostConstruct(postConstructMethod string) error {
+	method, err := newLifecycleMethod(postConstructMethod, l.structureValue.MethodByName(postConstructMethod))
+	if err != nil {
+		return l.invalid(err.Error())
+	}
+	l.postConstruct = method
+	return nil
+}
+
+// initializePreDestroy initializes the pre destroy function for the leaf, returning an error if it's invalid
+func (l *leaf) initializePreDestroy(preDestroyMethod string) error {
+	method, err := newLifecycleMethod(preDestroyMethod, l.structureValue.MethodByName(preDestroyMethod))
+	if err != nil {
+		return l.invalid(err.Error())
+	}
+	l.preDestroy = method
+	return nil
+}
+
+// invalid constructs an ErrInvalidLeaf error for the leaf structure with the supplied reason
+func (l *leaf) invalid(reason string) error {
+	return fmt.Errorf("%w: %s - %s", ErrInvalidLeaf, l.structureType.String(), reason)
+}
+
+// instance gets the value to inject for the leaf. Singletons always return the same structure pointer, while prototypes
+// create a new instance on every call, resolving its dependencies in the tree the leaf was added to and calling its
+// PostConstruct function (or leaving that to the grow, if the instance is being injected while the tree is grown).
+// Nothing is constructed here, so singletons that haven't been constructed yet fail with ErrNotConstructed
+func (l *leaf) instance(ctx context.Context, tree *Tree) (reflect.Value, error) {
+
+	// Leaves from a parent tree are read and wired with the parent locked
+	if owner := tree.owner(l); owner != tree {
+		owner.mutex.RLock()
+		defer owner.mutex.RUnlock()
+		tree = owner
+	}
+
+	if l.scope != prototype {
+		if !l.structureValue.IsValid() {
+			return reflect.Value{}, fmt.Errorf("%w: leaf %s has not been constructed yet", ErrNotConstructed, l.name)
+		}
+		return l.structureValue, nil
+	}
+
+	// Make sure we're not already creating this prototype further up the stack, which would never end. The prototypes
+	// being created are tracked in the context, so instances can be created from several goroutines at once
+	creating, _ := ctx.Value(creatingKey{}).(map[*leaf]bool)
+	if creating[l] {
+		return reflect.Value{}, fmt.Errorf("circular prototype dependency on leaf %s", l.name)
+	} else if creating == nil {
+		creating = make(map[*leaf]bool)
+		ctx = context.WithValue(ctx, creatingKey{}, creating)
+	}
+	creating[l] = true
+	defer delete(creating, l)
+
+	// Build the new structure, either with the provider or by copying the registered structure
+	var value reflect.Value
+	if l.provider != nil {
+		provided, _, err := l.provider.call(ctx, tree, nil)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%w %s: %w", ErrConstruction, l.name, err)
+		}
+		value = provided
+	} else {
+		value = reflect.New(l.structureType)
+		value.Elem().Set(l.structureElement)
+	}
+
+	// Wire up the new instance as its own leaf
+	instance, err := newNamedLeaf(tree.config, l.name, value.Interface())
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	instance.postConstructTimeout = l.postConstructTimeout
+	if err := instance.resolveDependencies(ctx, tree); err != nil {
+		return reflect.Value{}, err
+	}
+	if missing := instance.missingDependencies(tree); len(missing) != 0 {
+		return reflect.Value{}, fmt.Errorf("%w: %s - %s", ErrUnresolvedDependencies, l.name, strings.Join(missing, ", "))
+	}
+
+	// While the tree is growing, leave PostConstruct until the leaf being wired is post-constructed, so the instance's
+	// dependencies are ready first
+	if injecting, ok := ctx.Value(injectingKey{}).(*leaf); ok {
+		injecting.instances = append(injecting.instances, instance)
+		return value, nil
+	}
+	if err := instance.callPostConstruct(ctx, tree.config); err != nil {
+		return reflect.Value{}, err
+	}
+
+	return value, nil
+}
+
+// missingDependencies describes the leaf's dependencies that can't be resolved in the supplied tree. Optional
+// dependencies that don't exist aren't included
+func (l *leaf) missingDependencies(tree *Tree) []string {
+	missing := make([]string, 0)
+	for _, dep := range l.sortedDependencies(l.unresolvedDependencies) {
+		if _, err := dep.resolve(tree); dep.missing(err) {
+			missing = append(missing, dep.field+": "+err.Error())
+		}
+	}
+
+	// Prototype providers are only called when an instance is needed, so check their parameters as well
+	if l.provider != nil && !l.structureValue.IsValid() {
+		for _, parameter := range l.provider.parameters {
+			if _, err := tree.resolveType(parameter); err != nil {
+				missing = append(missing, err.Error())
+			}
+		}
+	}
+
+	return missing
+}
+
+// construct builds the leaf using its provider, constructing any provider leaves it depends on first. Prototypes
+// construct the leaves their instances will be wired to instead, since instances are created without constructing
+// anything. Leaves that were supplied as structure pointers, have already been constructed or belong to a parent tree
+// are left alone. The tree must be locked for writing
+func (l *leaf) construct(ctx context.Context, tree *Tree, constructing map[*leaf]bool) error {
+	if tree.owner(l) != tree {
+		return nil
+	} else if l.scope == prototype {
+		return l.constructEdges(ctx, tree, constructing)
+	} else if l.provider == nil || l.structureValue.IsValid() {
+		return nil
+	}
+
+	if constructing[l] {
+		return fmt.Errorf("%w %s: circular provider dependency", ErrConstruction, l.name)
+	}
+	constructing[l] = true
+	defer delete(constructing, l)
+
+	value, arguments, err := l.provider.call(context.WithValue(ctx, injectingKey{}, l), tree, constructing)
+	if err != nil {
+		return fmt.Errorf("%w %s: %w", ErrConstruction, l.name, err)
+	}
+	l.provider.arguments = arguments
+
+	l.structureValue = value
+	l.structureElement = value.Elem()
+	return l.initialize(tree.config)
+}
+
+// constructEdges constructs the leaves a prototype's instances will be wired to. Prototypes that are already being
+// visited further up the stack are skipped, since they only fail once an instance is created
+func (l *leaf) constructEdges(ctx context.Context, tree *Tree, constructing map[*leaf]bool) error {
+	if constructing[l] {
+		return nil
+	}
+	constructing[l] = true
+	defer delete(constructing, l)
+
+	for _, edge := range l.graphEdges(tree) {
+		if err := edge.to.construct(ctx, tree, constructing); err != nil {
+			return err
 		}
 	}
+	return nil
 }
 
-// initializePostConstruct initializes the post construct function for the leaf, panicking if it's invalid
-func (l *leaf) initializePostConstruct(postConstructMethod string) {
-	l.postConstruct = l.structureValue.MethodByName(postConstructMethod)
-	if !l.postConstruct.IsValid() {
-		return
+// keepRegistered copies the structure as it was registered if the leaf is recreated from it on refresh. Provider leaves
+// call their provider again instead
+func (l *leaf) keepRegistered() {
+	if l.recreate && l.provider == nil {
+		l.registered = copyStructure(l.structureValue.Interface())
 	}
+}
+
+// pointerType gets the type of the pointer to the leaf structure, which is what gets injected into other leaves
+func (l *leaf) pointerType() reflect.Type {
+	return reflect.PtrTo(l.structureType)
+}
 
-	if l.postConstruct.Type().NumIn() != 0 {
-		panic(l.structureType.String() + " - " + postConstructMethod + " must not take any parameters")
-	} else if l.postConstruct.Type().NumOut() != 0 {
-		panic(l.structureType.String() + " - " + postConstructMethod + " must not return any parameters")
+// sortedDependencies sorts the supplied dependencies by the order their fields are declared in
+func (l *leaf) sortedDependencies(dependencies map[string]*dependency) []*dependency {
+	sorted := make([]*dependency, 0, len(dependencies))
+	for _, dep := range dependencies {
+		sorted = append(sorted, dep)
 	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].index < sorted[j].index
+	})
+	return sorted
 }
 
-// initializePreDestroy initializes the pre destroy function for the leaf, panicking if it's invalid
-func (l *leaf) initializePreDestroy(preDestroyMethod string) {
-	l.preDestroy = l.structureValue.MethodByName(preDestroyMethod)
-	if !l.preDestroy.IsValid() {
-		return
+// allDependencies gets the resolved and unresolved dependencies, sorted by the order their fields are declared in
+func (l *leaf) allDependencies() []*dependency {
+	dependencies := make(map[string]*dependency, len(l.resolvedDependencies)+len(l.unresolvedDependencies))
+	for field, dep := range l.resolvedDependencies {
+		dependencies[field] = dep
 	}
+	for field, dep := range l.unresolvedDependencies {
+		dependencies[field] = dep
+	}
+	return l.sortedDependencies(dependencies)
+}
 
-	if l.preDestroy.Type().NumIn() != 0 {
-		panic(l.structureType.String() + " - " + preDestroyMethod + " must not take any parameters")
-	} else if l.preDestroy.Type().NumOut() != 0 {
-		panic(l.structureType.String() + " - " + preDestroyMethod + " must not return any parameters")
+// resolveDependencies resolves dependencies for the leaf using the supplied tree. Dependencies that can't be found are
+// left unresolved, and an error is only returned if a dependency that was found can't be set
+func (l *leaf) resolveDependencies(ctx context.Context, tree *Tree) error {
+	for _, dep := range l.sortedDependencies(l.unresolvedDependencies) {
+		leaves, err := dep.resolve(tree)
+		if err != nil {
+			continue
+		}
+		if err := l.setDependency(ctx, tree, dep, leaves); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-// resolveDependencies resolves dependencies for the leaf using the supplied tree
-func (l *leaf) resolveDependencies(tree *Tree) {
-	for name := range l.unresolvedDependencies {
-		dep := tree.GetLeaf(name)
-		if dep != nil {
-			l.setDependency(tree, name, dep)
+// unwireGroups moves the group dependencies whose members have changed since they were injected back to the unresolved
+// dependencies, so growing the tree again injects the new members
+func (l *leaf) unwireGroups(tree *Tree) {
+	for field, dep := range l.resolvedDependencies {
+		if !dep.byGroup() {
+			continue
 		}
+		if members, err := dep.resolve(tree); err == nil && sameLeaves(members, dep.leaves) {
+			continue
+		}
+		l.unresolvedDependencies[field] = dep
+		delete(l.resolvedDependencies, field)
 	}
 }
 
-// setDependency sets a dependency in the leaf
-func (l *leaf) setDependency(tree *Tree, name string, leaf *leaf) {
-	if !l.unresolvedDependencies[name].IsValid() {
-		panic("Can't set dependency " + name + "in leaf" + l.name)
+// setDependency sets a dependency in the leaf to the supplied leaves
+func (l *leaf) setDependency(ctx context.Context, tree *Tree, dep *dependency, leaves []*leaf) error {
+	if !dep.value.IsValid() || !dep.value.CanSet() {
+		return fmt.Errorf("%w: %s - can't set dependency %s", ErrInvalidLeaf, l.name, dep.field)
+	}
+
+	value, err := dep.inject(ctx, tree, leaves)
+	if err != nil {
+		return err
 	}
 
 	// Set the dependency and move it to "resolved"
-	l.unresolvedDependencies[name].Set(leaf.structureValue)
-	l.resolvedDependencies[name] = l.unresolvedDependencies[name]
-	delete(l.unresolvedDependencies, name)
+	dep.value.Set(value)
+	dep.leaves = leaves
+	l.resolvedDependencies[dep.field] = dep
+	delete(l.unresolvedDependencies, dep.field)
+	return nil
+}
+
+// dependencyLeaves gets the leaves this leaf has been wired to, starting with the provider parameters and followed by the
+// fields in declaration order
+func (l *leaf) dependencyLeaves() []*leaf {
+	leaves := make([]*leaf, 0)
+	if l.provider != nil && l.structureValue.IsValid() {
+		leaves = append(leaves, l.provider.arguments...)
+	}
+	for _, dep := range l.sortedDependencies(l.resolvedDependencies) {
+		leaves = append(leaves, dep.leaves...)
+	}
+	return leaves
+}
+
+// callPostConstruct calls the leaf's PostConstruct method with the supplied context if it has one, giving up once the
+// leaf's post construct timeout (or the configured one) has passed
+func (l *leaf) callPostConstruct(ctx context.Context, config *config) error {
+	return l.callLifecycle(ctx, l.postConstruct, l.timeout(l.postConstructTimeout, config.postConstructTimeout))
 }
 
-// dependenciesResolved determines if dependencies have been resolved
-func (l *leaf) dependenciesResolved() bool {
-	return len(l.unresolvedDependencies) == 0
+// callInstancePostConstructs calls PostConstruct on the prototype instances the leaf was injected with while the tree
+// was grown, in the order they were created, stopping at the first failure
+func (l *leaf) callInstancePostConstructs(ctx context.Context, config *config) error {
+	for len(l.instances) != 0 {
+		if err := l.instances[0].callPostConstruct(ctx, config); err != nil {
+			return err
+		}
+		l.instances = l.instances[1:]
+	}
+	return nil
 }
 
-// callPostConstruct calls the leaf's PostConstruct method if it has one
-func (l *leaf) callPostConstruct() {
-	if !l.postConstruct.IsValid() {
-		return
+// callPreDestroy calls the leaf's PreDestroy method with the supplied context if it has one, giving up once the leaf's
+// pre destroy timeout (or the configured one) has passed
+func (l *leaf) callPreDestroy(ctx context.Context, config *config) error {
+	return l.callLifecycle(ctx, l.preDestroy, l.timeout(l.preDestroyTimeout, config.preDestroyTimeout))
+}
+
+// timeout picks the leaf timeout if it's been set and the configured timeout otherwise, where zero means no timeout
+func (l *leaf) timeout(leafTimeout time.Duration, configTimeout time.Duration) time.Duration {
+	if leafTimeout < 0 {
+		return 0
+	} else if leafTimeout > 0 {
+		return leafTimeout
+	}
+	return configTimeout
+}
+
+// callLifecycle calls the supplied lifecycle method if the leaf has it, wrapping any failure in a LifecycleError. If
+// there's a timeout or the context can be cancelled, the method is called in a separate goroutine, and the leaf is
+// reported as hung if the timeout passes or the context is done before the method returns
+func (l *leaf) callLifecycle(ctx context.Context, method *lifecycleMethod, timeout time.Duration) error {
+	if method == nil {
+		return nil
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
-	l.postConstruct.Call([]reflect.Value{})
+
+	var err error
+	if ctx.Done() == nil {
+		err = method.call(ctx)
+	} else {
+		err = l.callAsync(ctx, method, timeout)
+	}
+
+	if err != nil {
+		return &LifecycleError{Leaf: l.name, Method: method.name, Err: err}
+	}
+	return nil
 }
 
-// callPreDestroy calls the leaf's PreDestroy method if it has one
-func (l *leaf) callPreDestroy() {
-	if !l.preDestroy.IsValid() {
-		return
+// callAsync calls the lifecycle method in a separate goroutine, returning its error or an error describing the hung
+// call if the context is done first. A hung method is left running in the background
+func (l *leaf) callAsync(ctx context.Context, method *lifecycleMethod, timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- method.call(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Prefer the method's result if it finished at the same time as the context
+	select {
+	case err := <-done:
+		return err
+	default:
+	}
+
+	if timeout > 0 && ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("still running after %s: %w", timeout, ctx.Err())
 	}
-	l.preDestroy.Call([]reflect.Value{})
+	return fmt.Errorf("still running when the context was done: %w", ctx.Err())
 }
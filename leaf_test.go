@@ -1,6 +1,7 @@
 package autumn
 
 import (
+	"context"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -37,22 +38,28 @@ func (b *bar) GetLeafName() string {
 	return "bar"
 }
 
+// mustLeaf fails the test if a leaf couldn't be constructed
+func mustLeaf(l *leaf, err error) *leaf {
+	So(err, ShouldBeNil)
+	return l
+}
+
 func TestNewLeaf(t *testing.T) {
 	Convey("Constructs a new leaf", t, func() {
 
 		Convey("Sets the leaf name", func() {
 
 			Convey("With a pointer receiver", func() {
-				So(newLeaf(NewConfig(), &namedByPointer{}).name, ShouldEqual, "namedByPointer")
+				So(mustLeaf(newLeaf(NewConfig(), &namedByPointer{})).name, ShouldEqual, "namedByPointer")
 			})
 
 			Convey("With a value receiver", func() {
-				So(newLeaf(NewConfig(), &namedByValue{}).name, ShouldEqual, "namedByValue")
+				So(mustLeaf(newLeaf(NewConfig(), &namedByValue{})).name, ShouldEqual, "namedByValue")
 			})
 		})
 
 		Convey("Sets the unresolved leaf dependencies", func() {
-			So(newLeaf(NewConfig(), &withDep{}).unresolvedDependencies, ShouldHaveLength, 1)
+			So(mustLeaf(newLeaf(NewConfig(), &withDep{})).unresolvedDependencies, ShouldHaveLength, 1)
 		})
 	})
 }
@@ -63,16 +70,16 @@ func TestNewNamedLeaf(t *testing.T) {
 		Convey("Sets the leaf name", func() {
 
 			Convey("With a pointer receiver", func() {
-				So(newNamedLeaf(NewConfig(), "test", &namedByPointer{}).name, ShouldEqual, "test")
+				So(mustLeaf(newNamedLeaf(NewConfig(), "test", &namedByPointer{})).name, ShouldEqual, "test")
 			})
 
 			Convey("With a value receiver", func() {
-				So(newNamedLeaf(NewConfig(), "test", &namedByValue{}).name, ShouldEqual, "test")
+				So(mustLeaf(newNamedLeaf(NewConfig(), "test", &namedByValue{})).name, ShouldEqual, "test")
 			})
 		})
 
 		Convey("Sets the unresolved leaf dependencies", func() {
-			So(newNamedLeaf(NewConfig(), "test", &withDep{}).unresolvedDependencies, ShouldHaveLength, 1)
+			So(mustLeaf(newNamedLeaf(NewConfig(), "test", &withDep{})).unresolvedDependencies, ShouldHaveLength, 1)
 		})
 	})
 }
@@ -83,12 +90,53 @@ func TestResolveDependencies(t *testing.T) {
 		f := &foo{}
 		b := &bar{}
 
-		fLeaf := newLeaf(NewConfig(), f)
-		bLeaf := newLeaf(NewConfig(), b)
+		fLeaf := mustLeaf(newLeaf(NewConfig(), f))
+		bLeaf := mustLeaf(newLeaf(NewConfig(), b))
+
+		tree := NewTree()
+		So(tree.add(fLeaf), ShouldBeNil)
+		So(tree.add(bLeaf), ShouldBeNil)
 
-		fLeaf.resolveDependencies(NewTree().add(fLeaf).add(bLeaf))
+		So(fLeaf.resolveDependencies(context.Background(), tree), ShouldBeNil)
 		So(f.Bar, ShouldEqual, b)
 		So(fLeaf.resolvedDependencies, ShouldHaveLength, 1)
 		So(fLeaf.unresolvedDependencies, ShouldHaveLength, 0)
 	})
 }
+
+type grouped struct{}
+
+func (g *grouped) GetLeafGroups() []string {
+	return []string{"first", "second"}
+}
+
+type badGroups struct{}
+
+func (b *badGroups) GetLeafGroups() string {
+	return "first"
+}
+
+func TestLeafGroups(t *testing.T) {
+	Convey("Initializes the leaf groups", t, func() {
+
+		Convey("Reads the groups method", func() {
+			So(mustLeaf(newLeaf(NewConfig(), &grouped{})).groups, ShouldResemble, []string{"first", "second"})
+		})
+
+		Convey("Reads the groups method for provider leaves", func() {
+			l := mustLeaf(newProviderLeaf(NewConfig(), func() *grouped { return &grouped{} }))
+			So(l.groups, ShouldResemble, []string{"first", "second"})
+		})
+
+		Convey("Ignores groups the leaf is already in", func() {
+			l := mustLeaf(newLeaf(NewConfig(), &grouped{}))
+			l.joinGroups("second", "third")
+			So(l.groups, ShouldResemble, []string{"first", "second", "third"})
+		})
+
+		Convey("Fails if the groups method has the wrong signature", func() {
+			_, err := newLeaf(NewConfig(), &badGroups{})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
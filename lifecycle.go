@@ -0,0 +1,82 @@
+package autumn
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// contextType is the reflection type of the context.Context interface
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// lifecycleMethod describes a PostConstruct or PreDestroy method. The method may optionally take a context and may
+// optionally return an error
+type lifecycleMethod struct {
+	name         string
+	method       reflect.Value
+	takesContext bool
+	returnsError bool
+}
+
+// newLifecycleMethod constructs a new lifecycle method from the supplied method value, returning nil if the method
+// doesn't exist and an error if its signature is invalid
+func newLifecycleMethod(name string, method reflect.Value) (*lifecycleMethod, error) {
+	if !method.IsValid() {
+		return nil, nil
+	}
+
+	methodType := method.Type()
+	if methodType.NumIn() > 1 || (methodType.NumIn() == 1 && methodType.In(0) != contextType) {
+		return nil, fmt.Errorf("%s must not take any parameters other than a context.Context", name)
+	} else if methodType.NumOut() > 1 || (methodType.NumOut() == 1 && methodType.Out(0) != errorType) {
+		return nil, fmt.Errorf("%s must not return any parameters other than an error", name)
+	}
+
+	return &lifecycleMethod{
+		name:         name,
+		method:       method,
+		takesContext: methodType.NumIn() == 1,
+		returnsError: methodType.NumOut() == 1,
+	}, nil
+}
+
+// call calls the lifecycle method with the supplied context, returning the error it returns or an error describing
+// the panic if it panics
+func (m *lifecycleMethod) call(ctx context.Context) error {
+	arguments := []reflect.Value{}
+	if m.takesContext {
+		arguments = append(arguments, reflect.ValueOf(ctx))
+	}
+
+	results, err := callFunction(m.method, arguments)
+	if err != nil {
+		return err
+	}
+	if m.returnsError && !results[0].IsNil() {
+		return results[0].Interface().(error)
+	}
+	return nil
+}
+
+// LifecycleError describes a failed PostConstruct or PreDestroy call. It matches ErrLifecycle with errors.Is, and
+// unwraps to the error returned by the method
+type LifecycleError struct {
+	Leaf   string
+	Method string
+	Err    error
+}
+
+// Error describes the failure
+func (e *LifecycleError) Error() string {
+	return ErrLifecycle.Error() + ": " + e.Leaf + " " + e.Method + " - " + e.Err.Error()
+}
+
+// Unwrap gets the error returned by the lifecycle method
+func (e *LifecycleError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrLifecycle, for use with errors.Is
+func (e *LifecycleError) Is(target error) bool {
+	return target == ErrLifecycle
+}
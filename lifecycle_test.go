@@ -0,0 +1,232 @@
+package autumn
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type contextKey struct{}
+
+type lifecycleSignatures struct {
+	ctx context.Context
+	err error
+}
+
+func (l *lifecycleSignatures) Plain()                          {}
+func (l *lifecycleSignatures) WithError() error                { return l.err }
+func (l *lifecycleSignatures) WithContext(ctx context.Context) { l.ctx = ctx }
+func (l *lifecycleSignatures) WithBoth(ctx context.Context) error {
+	l.ctx = ctx
+	return l.err
+}
+func (l *lifecycleSignatures) WithInt(int)        {}
+func (l *lifecycleSignatures) WithString() string { return "" }
+
+type failingLifecycle struct {
+	Err error
+}
+
+func (f *failingLifecycle) PostConstruct(ctx context.Context) error {
+	return f.Err
+}
+
+func (f *failingLifecycle) PreDestroy() error {
+	return f.Err
+}
+
+type contextLifecycle struct {
+	pcValue interface{}
+	pdValue interface{}
+}
+
+func (c *contextLifecycle) PostConstruct(ctx context.Context) {
+	c.pcValue = ctx.Value(contextKey{})
+}
+
+func (c *contextLifecycle) PreDestroy(ctx context.Context) {
+	c.pdValue = ctx.Value(contextKey{})
+}
+
+type reentrantLifecycle struct {
+	Tree        *Tree
+	growErr     error
+	overrideErr error
+	chopErr     error
+}
+
+func (r *reentrantLifecycle) PostConstruct(ctx context.Context) {
+	r.growErr = r.Tree.GrowContext(ctx)
+	r.overrideErr = r.Tree.OverrideE("autumn.noop", &noop{})
+}
+
+func (r *reentrantLifecycle) PreDestroy(ctx context.Context) {
+	r.chopErr = r.Tree.ChopContext(ctx)
+}
+
+func lifecycleMethodFor(l *lifecycleSignatures, name string) (*lifecycleMethod, error) {
+	return newLifecycleMethod(name, reflect.ValueOf(l).MethodByName(name))
+}
+
+func TestNewLifecycleMethod(t *testing.T) {
+	Convey("Constructs a new lifecycle method", t, func() {
+		l := &lifecycleSignatures{}
+
+		Convey("Returns nil if the method doesn't exist", func() {
+			method, err := lifecycleMethodFor(l, "Missing")
+			So(err, ShouldBeNil)
+			So(method, ShouldBeNil)
+		})
+
+		Convey("Accepts the supported signatures", func() {
+			for _, name := range []string{"Plain", "WithError", "WithContext", "WithBoth"} {
+				method, err := lifecycleMethodFor(l, name)
+				So(err, ShouldBeNil)
+				So(method, ShouldNotBeNil)
+			}
+		})
+
+		Convey("Fails if the method takes something other than a context", func() {
+			_, err := lifecycleMethodFor(l, "WithInt")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Fails if the method returns something other than an error", func() {
+			_, err := lifecycleMethodFor(l, "WithString")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestCallLifecycleMethod(t *testing.T) {
+	Convey("Calls a lifecycle method", t, func() {
+		l := &lifecycleSignatures{err: errors.New("failed")}
+		ctx := context.WithValue(context.Background(), contextKey{}, "value")
+
+		Convey("Passes the context", func() {
+			method, _ := lifecycleMethodFor(l, "WithContext")
+			So(method.call(ctx), ShouldBeNil)
+			So(l.ctx, ShouldEqual, ctx)
+		})
+
+		Convey("Returns the method error", func() {
+			method, _ := lifecycleMethodFor(l, "WithBoth")
+			So(method.call(ctx), ShouldEqual, l.err)
+		})
+	})
+}
+
+func TestLifecycleContext(t *testing.T) {
+	Convey("Passes contexts to the lifecycle methods", t, func() {
+		ctx := context.WithValue(context.Background(), contextKey{}, "value")
+		leaf := &contextLifecycle{}
+		tree := NewTree().AddLeaf(leaf)
+
+		So(tree.GrowContext(ctx), ShouldBeNil)
+		So(leaf.pcValue, ShouldEqual, "value")
+
+		So(tree.ChopContext(ctx), ShouldBeNil)
+		So(leaf.pdValue, ShouldEqual, "value")
+	})
+
+	Convey("Propagates lifecycle errors", t, func() {
+		failure := errors.New("failed to open connection")
+		tree := NewTree().AddLeaf(&failingLifecycle{Err: failure})
+
+		err := tree.GrowE()
+		So(errors.Is(err, ErrLifecycle), ShouldBeTrue)
+		So(errors.Is(err, failure), ShouldBeTrue)
+
+		var lifecycleErr *LifecycleError
+		So(errors.As(tree.ChopE(), &lifecycleErr), ShouldBeTrue)
+		So(lifecycleErr.Leaf, ShouldEqual, "autumn.failingLifecycle")
+		So(lifecycleErr.Method, ShouldEqual, "PreDestroy")
+	})
+
+	Convey("Stops growing if the context is done", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		leaf := &contextLifecycle{}
+		So(errors.Is(NewTree().AddLeaf(leaf).GrowContext(ctx), context.Canceled), ShouldBeTrue)
+		So(leaf.pcValue, ShouldBeNil)
+	})
+
+	Convey("Fails instead of hanging when a lifecycle function grows or chops its own tree", t, func() {
+		tree := NewTree()
+		leaf := &reentrantLifecycle{Tree: tree}
+		So(tree.AddLeaf(leaf).AddLeaf(&noop{}).GrowE(), ShouldBeNil)
+		So(errors.Is(leaf.growErr, ErrReentrant), ShouldBeTrue)
+		So(leaf.overrideErr, ShouldNotBeNil)
+
+		So(tree.ChopE(), ShouldBeNil)
+		So(errors.Is(leaf.chopErr, ErrReentrant), ShouldBeTrue)
+	})
+
+	Convey("Lets lifecycle functions grow other trees", t, func() {
+		other := NewTree()
+		leaf := &reentrantLifecycle{Tree: other}
+		So(NewTree().AddLeaf(leaf).GrowE(), ShouldBeNil)
+		So(leaf.growErr, ShouldBeNil)
+	})
+}
+
+type hangingLifecycle struct {
+	release chan struct{}
+}
+
+func (h *hangingLifecycle) PostConstruct() {
+	<-h.release
+}
+
+func (h *hangingLifecycle) PreDestroy() {
+	<-h.release
+}
+
+func TestLifecycleTimeouts(t *testing.T) {
+	Convey("Gives up on hung lifecycle methods", t, func() {
+		hung := &hangingLifecycle{release: make(chan struct{})}
+		defer close(hung.release)
+
+		Convey("Fails the grow with the configured timeout", func() {
+			tree := NewTree().Configure(NewConfig().PostConstructTimeout(10 * time.Millisecond)).AddLeaf(hung)
+
+			var lifecycleErr *LifecycleError
+			err := tree.GrowE()
+			So(errors.As(err, &lifecycleErr), ShouldBeTrue)
+			So(lifecycleErr.Leaf, ShouldEqual, "autumn.hangingLifecycle")
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+		})
+
+		Convey("Fails the grow with the leaf timeout", func() {
+			err := NewTree().AddLeaf(hung, PostConstructTimeout(10*time.Millisecond)).GrowE()
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+		})
+
+		Convey("Lets the leaf wait forever with a negative timeout", func() {
+			c := &child{}
+			tree := NewTree().
+				Configure(NewConfig().PostConstructTimeout(10*time.Millisecond)).
+				AddLeaf(c, PostConstructTimeout(-1))
+			So(tree.GrowE(), ShouldBeNil)
+			So(c.pcValue, ShouldEqual, 1)
+		})
+
+		Convey("Moves on to the remaining leaves when chopping", func() {
+			c := &child{}
+			tree := NewTree().
+				Configure(NewConfig().PreDestroyTimeout(10*time.Millisecond)).
+				AddLeaf(c).
+				AddLeaf(hung, PostConstructTimeout(-1))
+
+			err := tree.ChopE()
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "autumn.hangingLifecycle PreDestroy")
+			So(c.pdValue, ShouldEqual, 1)
+		})
+	})
+}
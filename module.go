@@ -0,0 +1,105 @@
+package autumn
+
+import "fmt"
+
+// Module bundles leaves, aliases, property defaults and other modules so they can be installed in a tree together.
+// Registrations are recorded in the order they're made and applied to the tree when the module is installed
+type Module struct {
+	name          string
+	registrations []func(tree *Tree) error
+}
+
+// NewModule constructs a new module. The name identifies the module, so a tree only installs one module with each name
+func NewModule(name string) *Module {
+	return &Module{
+		name:          name,
+		registrations: make([]func(tree *Tree) error, 0),
+	}
+}
+
+// Name gets the name of the module
+func (m *Module) Name() string {
+	return m.name
+}
+
+// AddLeaf adds a leaf to the module, which is added to the tree like Tree.AddLeaf when the module is installed. Every
+// tree gets its own copy of the structure, so the supplied pointer itself is never wired
+func (m *Module) AddLeaf(value interface{}, options ...LeafOption) *Module {
+	return m.register(func(tree *Tree) error {
+		return tree.AddLeafE(copyLeaf(value), options...)
+	})
+}
+
+// AddNamedLeaf adds a named leaf to the module, which is added to the tree like Tree.AddNamedLeaf when the module is
+// installed. Every tree gets its own copy of the structure, so the supplied pointer itself is never wired
+func (m *Module) AddNamedLeaf(name string, value interface{}, options ...LeafOption) *Module {
+	return m.register(func(tree *Tree) error {
+		return tree.AddNamedLeafE(name, copyLeaf(value), options...)
+	})
+}
+
+// AddProvider adds a provider leaf to the module, which is added to the tree like Tree.AddProvider when the module is
+// installed
+func (m *Module) AddProvider(function interface{}, options ...LeafOption) *Module {
+	return m.register(func(tree *Tree) error {
+		return tree.AddProviderE(function, options...)
+	})
+}
+
+// AddNamedProvider adds a named provider leaf to the module, which is added to the tree like Tree.AddNamedProvider when
+// the module is installed
+func (m *Module) AddNamedProvider(name string, function interface{}, options ...LeafOption) *Module {
+	return m.register(func(tree *Tree) error {
+		return tree.AddNamedProviderE(name, function, options...)
+	})
+}
+
+// AddAlias adds an alias to a leaf, which is added to the tree like Tree.AddAlias when the module is installed. The
+// leaf may come from this module, a module installed before it or the tree itself
+func (m *Module) AddAlias(name string, alias ...string) *Module {
+	return m.register(func(tree *Tree) error {
+		return tree.AddAliasE(name, alias...)
+	})
+}
+
+// SetDefault sets a default property value in the tree's environment when the module is installed, so the module's
+// leaves can be configured without the application having to set every property
+func (m *Module) SetDefault(key string, value string) *Module {
+	return m.register(func(tree *Tree) error {
+		tree.environment.SetDefault(key, value)
+		return nil
+	})
+}
+
+// Install installs other modules when this module is installed, unless the tree already has them
+func (m *Module) Install(modules ...*Module) *Module {
+	return m.register(func(tree *Tree) error {
+		return tree.InstallE(modules...)
+	})
+}
+
+// copyLeaf copies a structure pointer registered with the module, so trees never share its lifecycle. The copy is
+// shallow, so any pointers, maps or slices in the structure are shared by the copies. Anything other than a structure
+// pointer is passed through for the tree to reject
+func copyLeaf(value interface{}) interface{} {
+	if !isStructurePointer(value) {
+		return value
+	}
+	return copyStructure(value).Addr().Interface()
+}
+
+// register records a registration to apply when the module is installed
+func (m *Module) register(registration func(tree *Tree) error) *Module {
+	m.registrations = append(m.registrations, registration)
+	return m
+}
+
+// install applies the module's registrations to the supplied tree, stopping at the first failure
+func (m *Module) install(tree *Tree) error {
+	for _, registration := range m.registrations {
+		if err := registration(tree); err != nil {
+			return fmt.Errorf("failed to install module %s: %w", m.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package autumn
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestModule(t *testing.T) {
+	Convey("Installs modules", t, func() {
+		storage := NewModule("storage").
+			AddNamedLeaf("memory", &memoryStore{}).
+			AddProvider(func() *diskStore { return &diskStore{} }, Primary()).
+			AddAlias("memory", "cache").
+			SetDefault("port", "5432")
+
+		Convey("Adds the module registrations to the tree", func() {
+			user := &storeUser{}
+			tree := NewTree().Install(storage).AddLeaf(user).Grow()
+
+			So(storage.Name(), ShouldEqual, "storage")
+			So(user.Memory.Store(), ShouldEqual, "memory")
+			So(user.Store.Store(), ShouldEqual, "disk")
+			So(tree.GetLeafValue("cache"), ShouldEqual, user.Memory)
+
+			port, _ := tree.Environment().Property("port")
+			So(port, ShouldEqual, "5432")
+		})
+
+		Convey("Gives every tree its own copy of the module's leaves", func() {
+			registered := &child{}
+			lifecycle := NewModule("lifecycle").AddLeaf(registered)
+			parent := NewTree()
+			first := parent.NewChild().Install(lifecycle).Grow()
+			second := parent.NewChild().Install(lifecycle).Grow()
+
+			firstChild := first.GetLeafValue("child").(*child)
+			secondChild := second.GetLeafValue("child").(*child)
+			So(firstChild, ShouldNotEqual, secondChild)
+			So(firstChild, ShouldNotEqual, registered)
+			So(firstChild.pcValue, ShouldEqual, 1)
+			So(secondChild.pcValue, ShouldEqual, 1)
+			So(registered.pcValue, ShouldEqual, 0)
+		})
+
+		Convey("Skips modules that are already installed", func() {
+			app := NewModule("app").Install(storage).AddNamedLeaf("user", &storeUser{})
+			tree := NewTree().Install(storage, app, storage)
+			So(tree.GrowE(), ShouldBeNil)
+		})
+
+		Convey("Skips modules installed in the parent tree", func() {
+			parent := NewTree().Install(storage).Grow()
+			child := parent.NewChild().Install(storage).AddLeaf(&storeUser{})
+			So(child.GrowE(), ShouldBeNil)
+		})
+
+		Convey("Handles modules that install each other", func() {
+			first := NewModule("first").AddNamedLeaf("first", &noop{})
+			second := NewModule("second").AddNamedLeaf("second", &noop{}).Install(first)
+			first.Install(second)
+
+			tree := NewTree().Install(first).Grow()
+			So(tree.GetLeaf("first"), ShouldNotBeNil)
+			So(tree.GetLeaf("second"), ShouldNotBeNil)
+		})
+
+		Convey("Fails if a registration fails", func() {
+			err := NewTree().AddNamedLeaf("memory", &noop{}).InstallE(storage)
+			So(errors.Is(err, ErrDuplicateName), ShouldBeTrue)
+			So(err.Error(), ShouldStartWith, "failed to install module storage")
+		})
+	})
+}
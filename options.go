@@ -0,0 +1,101 @@
+package autumn
+
+import "time"
+
+// LeafOption configures a leaf as it's added to the tree
+type LeafOption func(*leaf)
+
+// Prototype marks a leaf as a prototype. Instead of sharing a single instance, a new instance of the leaf is created,
+// wired and post-constructed for every leaf it's injected into and every time it's retrieved from the tree
+func Prototype() LeafOption {
+	return func(l *leaf) {
+		l.scope = prototype
+	}
+}
+
+// Primary marks a leaf as the primary candidate when wiring by type, so it's injected instead of reporting an ambiguity
+// when several leaves can be assigned to the same field or provider parameter
+func Primary() LeafOption {
+	return func(l *leaf) {
+		l.primary = true
+	}
+}
+
+// PostConstructTimeout overrides the configured post construct timeout for a leaf. A negative duration waits forever
+func PostConstructTimeout(timeout time.Duration) LeafOption {
+	return func(l *leaf) {
+		l.postConstructTimeout = timeout
+	}
+}
+
+// PreDestroyTimeout overrides the configured pre destroy timeout for a leaf. A negative duration waits forever
+func PreDestroyTimeout(timeout time.Duration) LeafOption {
+	return func(l *leaf) {
+		l.preDestroyTimeout = timeout
+	}
+}
+
+// Groups adds a leaf to the supplied groups, in addition to any returned by the leaf's groups method. Every leaf in a
+// group can be injected into a slice or map field with a "group:<name>" tag
+func Groups(groups ...string) LeafOption {
+	return func(l *leaf) {
+		l.joinGroups(groups...)
+	}
+}
+
+// KeepOnRefresh leaves the leaf alone when the tree is refreshed, even if it was built by a provider. The leaf isn't
+// pre-destroyed, wired or post-constructed again, so it should only depend on other leaves that are kept
+func KeepOnRefresh() LeafOption {
+	return func(l *leaf) {
+		l.keep = true
+	}
+}
+
+// RecreateOnRefresh makes refreshing the tree replace a leaf that was supplied as a structure pointer with a fresh copy
+// of the structure as it was added, instead of wiring the same instance again. The copy is shallow, so any pointers,
+// maps, slices or mutexes in the structure are shared with (or copied from) the destroyed instance, and pointers held
+// elsewhere keep pointing at the destroyed instance
+func RecreateOnRefresh() LeafOption {
+	return func(l *leaf) {
+		l.recreate = true
+	}
+}
+
+// Profiles only adds a leaf to the tree when one of the supplied profiles is active as the tree is grown. A profile
+// starting with "!" is active when the profile after it isn't
+func Profiles(profiles ...string) LeafOption {
+	return func(l *leaf) {
+		l.conditions = append(l.conditions, profileCondition(profiles))
+	}
+}
+
+// OnProperty only adds a leaf to the tree when the property is set as the tree is grown. If values are supplied, the
+// property must be set to one of them
+func OnProperty(key string, values ...string) LeafOption {
+	return func(l *leaf) {
+		l.conditions = append(l.conditions, propertyCondition(key, values))
+	}
+}
+
+// OnMissingLeaf only adds a leaf to the tree when none of the named leaves exist as the tree is grown. Without any
+// names, the leaf's own name is checked, so a default leaf steps aside when another leaf with its name is added
+func OnMissingLeaf(names ...string) LeafOption {
+	return func(l *leaf) {
+		l.conditions = append(l.conditions, leafCondition(names, false))
+	}
+}
+
+// OnPresentLeaf only adds a leaf to the tree when all of the named leaves exist as the tree is grown
+func OnPresentLeaf(names ...string) LeafOption {
+	return func(l *leaf) {
+		l.conditions = append(l.conditions, leafCondition(names, true))
+	}
+}
+
+// OnCondition only adds a leaf to the tree when the predicate returns true as the tree is grown. The description is
+// used to explain the outcome in the tree's condition report
+func OnCondition(description string, predicate func(tree *Tree) bool) LeafOption {
+	return func(l *leaf) {
+		l.conditions = append(l.conditions, predicateCondition(description, predicate))
+	}
+}
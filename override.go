@@ -0,0 +1,116 @@
+package autumn
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Override replaces a leaf that's been added to the tree with a fake, which is handy for swapping real implementations
+// for stubs in tests. The fake takes over the leaf's name, aliases, groups and primary flag, and the supplied options
+// are applied to it. Panics if the leaf doesn't exist, the tree has already been grown or the fake can't be wired
+// everywhere the leaf is
+func (t *Tree) Override(name string, fake interface{}, options ...LeafOption) *Tree {
+	return t.must(t.OverrideE(name, fake, options...))
+}
+
+// OverrideE replaces a leaf with a fake like Override, returning ErrLeafNotFound if the leaf doesn't exist and
+// ErrWrongType if the fake can't be assigned to a field or provider parameter that the leaf would be wired to
+func (t *Tree) OverrideE(name string, fake interface{}, options ...LeafOption) error {
+	if err := t.checkType(fake); err != nil {
+		return err
+	}
+	replacement, err := newNamedLeaf(t.configuration(), name, fake)
+	if err != nil {
+		return err
+	}
+
+	// Overriding only makes sense before the tree is grown, so don't wait for a grow (which may be the caller's own)
+	if !t.lifecycle.TryLock() {
+		return fmt.Errorf("leaf %s can't be overridden while the tree is growing or being chopped", name)
+	}
+	defer t.lifecycle.Unlock()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	original, ok := t.leaves[name]
+	if !ok {
+		return fmt.Errorf("%w: no leaf named %s exists", ErrLeafNotFound, name)
+	} else if original.state != LeafRegistered {
+		return fmt.Errorf("leaf %s can't be overridden after the tree has been grown", name)
+	}
+
+	// The fake takes over the original leaf, even if it was found by an alias
+	replacement.name = original.name
+	replacement.joinGroups(original.groups...)
+	replacement.primary = original.primary
+	for _, option := range options {
+		option(replacement)
+	}
+	replacement.keepRegistered()
+
+	if err := t.checkReplacement(original, replacement); err != nil {
+		return err
+	}
+
+	// Point the name and all of the aliases at the fake
+	for key, leaf := range t.leaves {
+		if leaf == original {
+			t.leaves[key] = replacement
+		}
+	}
+	return nil
+}
+
+// checkReplacement makes sure the replacement leaf can be wired to every field and provider parameter that the
+// original leaf would be wired to. The tree must be locked
+func (t *Tree) checkReplacement(original *leaf, replacement *leaf) error {
+	leaves := append([]*leaf{}, t.pending...)
+	for _, leafName := range t.addedLeaves {
+		leaves = append(leaves, t.leaves[leafName])
+	}
+
+	for _, leaf := range leaves {
+		if leaf == original {
+			continue
+		}
+
+		for _, dep := range leaf.allDependencies() {
+			target, wired := t.dependencyTarget(dep, original)
+			if wired && !replacement.pointerType().AssignableTo(target) {
+				return t.replacementError(original, replacement, leaf.name+"."+dep.field, target)
+			}
+		}
+
+		if leaf.provider == nil || leaf.structureValue.IsValid() {
+			continue
+		}
+		for i, parameter := range leaf.provider.parameters {
+			if found, err := t.resolveType(parameter); err == nil && found == original &&
+				!replacement.pointerType().AssignableTo(parameter) {
+				return t.replacementError(original, replacement, fmt.Sprintf("%s parameter %d", leaf.name, i), parameter)
+			}
+		}
+	}
+	return nil
+}
+
+// dependencyTarget determines if the supplied dependency would be wired to the leaf, returning the type the leaf is
+// assigned to
+func (t *Tree) dependencyTarget(dep *dependency, leaf *leaf) (reflect.Type, bool) {
+	switch {
+	case dep.byProperty():
+		return nil, false
+	case dep.byGroup():
+		return dep.value.Type().Elem(), leaf.inGroup(dep.group)
+	case dep.byType():
+		found, err := t.resolveType(dep.value.Type())
+		return dep.value.Type(), err == nil && found == leaf
+	}
+	return dep.value.Type(), t.getLeaf(dep.name) == leaf
+}
+
+// replacementError describes a fake that can't be assigned to somewhere the original leaf is wired to
+func (t *Tree) replacementError(original *leaf, replacement *leaf, target string, targetType reflect.Type) error {
+	return fmt.Errorf("%w: %s can't replace leaf %s, because it can't be assigned to %s of type %s", ErrWrongType,
+		replacement.pointerType().String(), original.name, target, targetType.String())
+}
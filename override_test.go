@@ -0,0 +1,75 @@
+package autumn
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type storeHolder struct {
+	Disk *diskStore `autumn:"disk"`
+	Any  store      `autumn:"disk"`
+}
+
+func TestOverride(t *testing.T) {
+	Convey("Overrides leaves with fakes", t, func() {
+
+		Convey("Replaces the leaf and its aliases", func() {
+			user := &storeUser{}
+			fake := &diskStore{}
+			tree := NewTree().
+				AddNamedLeaf("memory", &memoryStore{}, Groups("stores")).
+				AddAlias("memory", "cache").
+				AddLeaf(user).
+				Override("cache", fake).
+				Grow()
+
+			So(user.Memory, ShouldEqual, fake)
+			So(user.Store, ShouldEqual, fake)
+			So(tree.GetLeafValue("memory"), ShouldEqual, fake)
+			So(tree.GetLeafValue("cache"), ShouldEqual, fake)
+			So(tree.Describe("memory").Groups, ShouldResemble, []string{"stores"})
+		})
+
+		Convey("Fails if the fake can't be assigned to a named dependency", func() {
+			err := NewTree().AddNamedLeaf("disk", &diskStore{}).AddLeaf(&storeHolder{}).OverrideE("disk", &memoryStore{})
+			So(errors.Is(err, ErrWrongType), ShouldBeTrue)
+			So(err.Error(), ShouldEqual, "leaf has the wrong type: *autumn.memoryStore can't replace leaf disk, "+
+				"because it can't be assigned to autumn.storeHolder.Disk of type *autumn.diskStore")
+		})
+
+		Convey("Fails if the fake can't be assigned to a type dependency", func() {
+			err := NewTree().AddNamedLeaf("memory", &memoryStore{}).AddLeaf(&storeUser{}).OverrideE("memory", &noop{})
+			So(errors.Is(err, ErrWrongType), ShouldBeTrue)
+		})
+
+		Convey("Fails if the fake can't be assigned to a group", func() {
+			err := NewTree().
+				AddNamedLeaf("memory", &memoryStore{}, Groups("stores")).
+				AddNamedLeaf("holder", &struct {
+					Stores []store `autumn:"group:stores"`
+				}{}).
+				OverrideE("memory", &noop{})
+			So(errors.Is(err, ErrWrongType), ShouldBeTrue)
+		})
+
+		Convey("Fails if the fake can't be assigned to a provider parameter", func() {
+			err := NewTree().
+				AddNamedLeaf("memory", &memoryStore{}).
+				AddProvider(func(m *memoryStore) *diskStore { return &diskStore{} }).
+				OverrideE("memory", &noop{})
+			So(errors.Is(err, ErrWrongType), ShouldBeTrue)
+		})
+
+		Convey("Fails if the leaf doesn't exist", func() {
+			err := NewTree().OverrideE("memory", &memoryStore{})
+			So(errors.Is(err, ErrLeafNotFound), ShouldBeTrue)
+		})
+
+		Convey("Fails once the tree has been grown", func() {
+			tree := NewTree().AddNamedLeaf("memory", &memoryStore{}).Grow()
+			So(func() { tree.Override("memory", &memoryStore{}) }, ShouldPanic)
+		})
+	})
+}
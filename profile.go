@@ -0,0 +1,50 @@
+package autumn
+
+import "strings"
+
+// profilesProperty is the property that lists the active profiles, separated by commas. It can be set in the process
+// environment with AUTUMN_PROFILES_ACTIVE
+const profilesProperty = "autumn.profiles.active"
+
+// splitProfiles splits a comma separated list of profiles, ignoring blank entries
+func splitProfiles(value string) []string {
+	profiles := make([]string, 0)
+	for _, profile := range strings.Split(value, ",") {
+		if profile = strings.TrimSpace(profile); profile != "" {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+// profilesMatch determines if any of the supplied profiles is active. A profile starting with "!" matches when the
+// profile after it isn't active
+func profilesMatch(profiles []string, active []string) bool {
+	for _, profile := range profiles {
+		negated := strings.HasPrefix(profile, "!")
+		if containsString(active, strings.TrimPrefix(profile, "!")) != negated {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString determines if the supplied slice contains a string
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// appendMissing appends the supplied values that aren't already in the slice
+func appendMissing(values []string, additions ...string) []string {
+	for _, addition := range additions {
+		if !containsString(values, addition) {
+			values = append(values, addition)
+		}
+	}
+	return values
+}
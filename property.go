@@ -0,0 +1,86 @@
+package autumn
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationType is the reflection type of time.Duration, which is parsed differently from other integers
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// isProperty determines if the supplied tag name is a property placeholder, like ${key} or ${key:default}
+func isProperty(name string) bool {
+	return strings.HasPrefix(name, "${") && strings.HasSuffix(name, "}")
+}
+
+// parseProperty parses a property placeholder into its key and default value, if it has one
+func parseProperty(name string) (key string, fallback string, hasFallback bool) {
+	placeholder := strings.TrimSuffix(strings.TrimPrefix(name, "${"), "}")
+	parts := strings.SplitN(placeholder, ":", 2)
+	if len(parts) == 2 {
+		return strings.TrimSpace(parts[0]), parts[1], true
+	}
+	return strings.TrimSpace(parts[0]), "", false
+}
+
+// isPropertyType determines if a property can be converted to the supplied type
+func isPropertyType(target reflect.Type) bool {
+	switch target.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// convertProperty converts a property value to the supplied type. Durations are parsed with time.ParseDuration
+func convertProperty(value string, target reflect.Type) (reflect.Value, error) {
+	converted := reflect.New(target).Elem()
+
+	if target == durationType {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		converted.SetInt(int64(duration))
+		return converted, nil
+	}
+
+	switch target.Kind() {
+	case reflect.String:
+		converted.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		converted.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, target.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		converted.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, target.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		converted.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, target.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		converted.SetFloat(parsed)
+	default:
+		return reflect.Value{}, fmt.Errorf("can't convert a property to %s", target.String())
+	}
+
+	return converted, nil
+}
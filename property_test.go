@@ -0,0 +1,105 @@
+package autumn
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type configured struct {
+	URL      string        `autumn:"${AUTUMN_TEST_DB_URL}"`
+	Port     int           `autumn:"${autumn.test.db.port:5432}"`
+	Debug    bool          `autumn:"${debug:false}"`
+	Ratio    float64       `autumn:"${ratio:0.5}"`
+	Timeout  time.Duration `autumn:"${timeout:5s}"`
+	Replicas uint8         `autumn:"${replicas},optional"`
+}
+
+func TestParseProperty(t *testing.T) {
+	Convey("Parses property placeholders", t, func() {
+		So(isProperty("${key}"), ShouldBeTrue)
+		So(isProperty("key"), ShouldBeFalse)
+
+		key, _, hasFallback := parseProperty("${DB_URL}")
+		So(key, ShouldEqual, "DB_URL")
+		So(hasFallback, ShouldBeFalse)
+
+		key, fallback, hasFallback := parseProperty("${db.url:postgres://localhost:5432}")
+		So(key, ShouldEqual, "db.url")
+		So(fallback, ShouldEqual, "postgres://localhost:5432")
+		So(hasFallback, ShouldBeTrue)
+	})
+}
+
+func TestConvertProperty(t *testing.T) {
+	Convey("Converts property values", t, func() {
+
+		Convey("Converts the supported types", func() {
+			value, err := convertProperty("42", reflect.TypeOf(int16(0)))
+			So(err, ShouldBeNil)
+			So(value.Interface(), ShouldEqual, int16(42))
+
+			value, err = convertProperty("1m30s", durationType)
+			So(err, ShouldBeNil)
+			So(value.Interface(), ShouldEqual, 90*time.Second)
+
+			value, err = convertProperty("true", reflect.TypeOf(false))
+			So(err, ShouldBeNil)
+			So(value.Interface(), ShouldEqual, true)
+		})
+
+		Convey("Fails for values that don't fit the type", func() {
+			_, err := convertProperty("300", reflect.TypeOf(uint8(0)))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestGrowProperties(t *testing.T) {
+	Convey("Injects properties", t, func() {
+		os.Setenv("AUTUMN_TEST_DB_URL", "postgres://db")
+		defer os.Unsetenv("AUTUMN_TEST_DB_URL")
+
+		Convey("Reads the environment and defaults", func() {
+			leaf := &configured{}
+			So(NewTree().AddLeaf(leaf).GrowE(), ShouldBeNil)
+			So(leaf.URL, ShouldEqual, "postgres://db")
+			So(leaf.Port, ShouldEqual, 5432)
+			So(leaf.Debug, ShouldBeFalse)
+			So(leaf.Ratio, ShouldEqual, 0.5)
+			So(leaf.Timeout, ShouldEqual, 5*time.Second)
+			So(leaf.Replicas, ShouldEqual, 0)
+		})
+
+		Convey("Reads properties set on the tree", func() {
+			leaf := &configured{}
+			So(NewTree().SetProperty("autumn.test.db.port", "6543").SetProperty("replicas", "3").AddLeaf(leaf).GrowE(), ShouldBeNil)
+			So(leaf.Port, ShouldEqual, 6543)
+			So(leaf.Replicas, ShouldEqual, 3)
+		})
+
+		Convey("Fails for missing required properties", func() {
+			os.Unsetenv("AUTUMN_TEST_DB_URL")
+			err := NewTree().AddLeaf(&configured{}).GrowE()
+			So(errors.Is(err, ErrUnresolvedDependencies), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "AUTUMN_TEST_DB_URL")
+		})
+
+		Convey("Fails for properties that don't fit the field", func() {
+			err := NewTree().SetProperty("timeout", "soon").AddLeaf(&configured{}).GrowE()
+			So(errors.Is(err, ErrUnresolvedDependencies), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, ErrInvalidProperty.Error())
+		})
+
+		Convey("Fails for fields that can't hold a property", func() {
+			holder := &struct {
+				Values []string `autumn:"${values}"`
+			}{}
+			So(errors.Is(NewTree().AddNamedLeafE("holder", holder), ErrInvalidLeaf), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,106 @@
+package autumn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// errorType is the reflection type of the built-in error interface
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// provider describes a function that constructs a leaf, with parameters that are resolved from the tree
+type provider struct {
+	function     reflect.Value
+	parameters   []reflect.Type
+	returnsError bool
+
+	// arguments are the leaves a singleton was constructed with
+	arguments []*leaf
+}
+
+// newProvider constructs a new provider, returning an error if the supplied function has an invalid signature
+func newProvider(function interface{}) (*provider, error) {
+	if !isFunction(function) {
+		return nil, fmt.Errorf("%w: please only supply functions to AddProvider/AddNamedProvider", ErrInvalidLeaf)
+	}
+
+	value := reflect.ValueOf(function)
+	functionType := value.Type()
+
+	if functionType.IsVariadic() {
+		return nil, invalidProvider(functionType, "providers must not be variadic")
+	} else if functionType.NumOut() < 1 || functionType.NumOut() > 2 {
+		return nil, invalidProvider(functionType, "providers must return a structure pointer and optionally an error")
+	} else if functionType.Out(0).Kind() != reflect.Ptr || functionType.Out(0).Elem().Kind() != reflect.Struct {
+		return nil, invalidProvider(functionType, "providers must return a structure pointer")
+	} else if functionType.NumOut() == 2 && functionType.Out(1) != errorType {
+		return nil, invalidProvider(functionType, "the second provider return value must be an error")
+	}
+
+	p := &provider{
+		function:     value,
+		parameters:   make([]reflect.Type, functionType.NumIn()),
+		returnsError: functionType.NumOut() == 2,
+	}
+	for i := range p.parameters {
+		p.parameters[i] = functionType.In(i)
+	}
+
+	return p, nil
+}
+
+// invalidProvider constructs an ErrInvalidLeaf error for the supplied provider type with the supplied reason
+func invalidProvider(functionType reflect.Type, reason string) error {
+	return fmt.Errorf("%w: %s - %s", ErrInvalidLeaf, functionType.String(), reason)
+}
+
+// structureType gets the type of the structure the provider constructs
+func (p *provider) structureType() reflect.Type {
+	return p.function.Type().Out(0).Elem()
+}
+
+// call resolves the provider parameters using the supplied tree and calls the provider function, returning the leaves
+// it was called with. Provider leaves the parameters depend on are only constructed if a constructing set is supplied,
+// which needs the tree locked for writing. The provider itself isn't changed, so prototype instances can be created
+// concurrently
+func (p *provider) call(ctx context.Context, tree *Tree, constructing map[*leaf]bool) (reflect.Value, []*leaf, error) {
+	leaves := make([]*leaf, len(p.parameters))
+	arguments := make([]reflect.Value, len(p.parameters))
+
+	for i, parameter := range p.parameters {
+		dep, err := tree.resolveType(parameter)
+		if err != nil {
+			return reflect.Value{}, nil, fmt.Errorf("parameter %d: %w", i, err)
+		}
+
+		// Make sure provider leaves we depend on are built before we use them, when we're allowed to build them
+		if constructing != nil {
+			if err := dep.construct(ctx, tree, constructing); err != nil {
+				return reflect.Value{}, nil, err
+			}
+		}
+
+		argument, err := dep.instance(ctx, tree)
+		if err != nil {
+			return reflect.Value{}, nil, err
+		}
+
+		leaves[i] = dep
+		arguments[i] = argument
+	}
+
+	results, err := callFunction(p.function, arguments)
+	if err != nil {
+		return reflect.Value{}, nil, err
+	}
+	if p.returnsError && !results[1].IsNil() {
+		return reflect.Value{}, nil, results[1].Interface().(error)
+	}
+	if results[0].IsNil() {
+		return reflect.Value{}, nil, errors.New("provider returned a nil structure pointer")
+	}
+
+	return results[0], leaves, nil
+}
@@ -0,0 +1,170 @@
+package autumn
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type database struct {
+	pcCalled bool
+}
+
+func (d *database) PostConstruct() {
+	d.pcCalled = true
+}
+
+type repository struct {
+	DB       *database
+	Self     *repository `autumn:"repository"`
+	pcCalled bool
+}
+
+func (r *repository) GetLeafName() string {
+	return "repository"
+}
+
+func (r *repository) PostConstruct() {
+	r.pcCalled = true
+}
+
+type service struct {
+	Repository *repository
+}
+
+func newRepository(db *database) (*repository, error) {
+	return &repository{DB: db}, nil
+}
+
+func newService(r *repository) *service {
+	return &service{Repository: r}
+}
+
+func TestNewProvider(t *testing.T) {
+	Convey("Constructs a new provider", t, func() {
+
+		Convey("Records the parameter types", func() {
+			p, err := newProvider(newRepository)
+			So(err, ShouldBeNil)
+			So(p.parameters, ShouldHaveLength, 1)
+			So(p.returnsError, ShouldBeTrue)
+		})
+
+		Convey("Fails if the supplied value isn't a function", func() {
+			_, err := newProvider(&database{})
+			So(errors.Is(err, ErrInvalidLeaf), ShouldBeTrue)
+		})
+
+		Convey("Fails if the function doesn't return a structure pointer", func() {
+			_, err := newProvider(func() database { return database{} })
+			So(errors.Is(err, ErrInvalidLeaf), ShouldBeTrue)
+		})
+
+		Convey("Fails if the second return value isn't an error", func() {
+			_, err := newProvider(func() (*database, bool) { return nil, false })
+			So(errors.Is(err, ErrInvalidLeaf), ShouldBeTrue)
+		})
+	})
+}
+
+func TestAddProvider(t *testing.T) {
+	Convey("Adds a provider leaf", t, func() {
+
+		Convey("Names the leaf with GetLeafName", func() {
+			tree := NewTree().AddProvider(newRepository)
+			So(tree.GetLeaf("repository"), ShouldNotBeNil)
+		})
+
+		Convey("Names the leaf with the structure name", func() {
+			tree := NewTree().AddProvider(newService)
+			So(tree.GetLeaf("autumn.service"), ShouldNotBeNil)
+		})
+
+		Convey("Names the leaf with the supplied name", func() {
+			tree := NewTree().AddNamedProvider("a", newService)
+			So(tree.GetLeaf("a"), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestGrowProviders(t *testing.T) {
+	Convey("Constructs provider leaves", t, func() {
+
+		Convey("Resolves the provider parameters by type", func() {
+			db := &database{}
+			tree := NewTree().AddProvider(newService).AddProvider(newRepository).AddLeaf(db).Grow()
+
+			s := tree.GetLeaf("autumn.service").structureValue.Interface().(*service)
+			So(s.Repository, ShouldNotBeNil)
+			So(s.Repository.DB, ShouldEqual, db)
+		})
+
+		Convey("Wires and constructs the provided leaf", func() {
+			tree := NewTree().AddProvider(newRepository).AddLeaf(&database{}).Grow()
+
+			r := tree.GetLeaf("repository").structureValue.Interface().(*repository)
+			So(r.Self, ShouldEqual, r)
+			So(r.pcCalled, ShouldBeTrue)
+		})
+
+		Convey("Panics if a parameter can't be resolved", func() {
+			So(func() {
+				NewTree().AddProvider(newRepository).Grow()
+			}, ShouldPanic)
+		})
+
+		Convey("Panics if a parameter is ambiguous", func() {
+			So(func() {
+				NewTree().AddProvider(newRepository).AddNamedLeaf("a", &database{}).AddNamedLeaf("b", &database{}).Grow()
+			}, ShouldPanic)
+		})
+
+		Convey("Panics if the provider fails", func() {
+			So(func() {
+				NewTree().AddProvider(func() (*database, error) { return nil, errors.New("failed") }).Grow()
+			}, ShouldPanic)
+		})
+
+		Convey("Wraps the provider's own error", func() {
+			failure := errors.New("failed to connect")
+			err := NewTree().AddProvider(func() (*database, error) { return nil, failure }).GrowE()
+			So(errors.Is(err, ErrConstruction), ShouldBeTrue)
+			So(errors.Is(err, failure), ShouldBeTrue)
+		})
+
+		Convey("Wraps the error for a parameter that can't be resolved", func() {
+			err := NewTree().AddProvider(newRepository).GrowE()
+			So(errors.Is(err, ErrConstruction), ShouldBeTrue)
+			So(errors.Is(err, ErrLeafNotFound), ShouldBeTrue)
+		})
+
+		Convey("Constructs the providers a prototype provider depends on before creating an instance", func() {
+			tree := NewTree().
+				AddProvider(func(*diskStore) *noop { return &noop{} }).
+				AddProvider(func(*memoryStore) *diskStore { return &diskStore{} }, Prototype()).
+				AddProvider(func() *memoryStore { return &memoryStore{} })
+			So(tree.GrowE(), ShouldBeNil)
+			So(tree.GetLeafValue("autumn.noop"), ShouldNotBeNil)
+		})
+
+		Convey("Fails to create a prototype instance before the providers it depends on are constructed", func() {
+			tree := NewTree().
+				AddProvider(func(*memoryStore) *diskStore { return &diskStore{} }, Prototype()).
+				AddProvider(func() *memoryStore { return &memoryStore{} })
+
+			_, err := GetByType[*diskStore](tree)
+			So(errors.Is(err, ErrNotConstructed), ShouldBeTrue)
+			So(tree.GetLeaf("autumn.memoryStore").structureValue.IsValid(), ShouldBeFalse)
+		})
+
+		Convey("Panics if the providers depend on each other", func() {
+			So(func() {
+				NewTree().
+					AddProvider(func(*repository) *database { return &database{} }).
+					AddProvider(newRepository).
+					Grow()
+			}, ShouldPanic)
+		})
+	})
+}
@@ -0,0 +1,121 @@
+package autumn
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Refresh chops the tree down, wires its leaves again and grows it, so changed properties are picked up. Panics if a
+// PreDestroy function fails or the tree can't be grown again
+func (t *Tree) Refresh() *Tree {
+	return t.must(t.RefreshE())
+}
+
+// RefreshE refreshes the tree like Refresh, returning an error instead of panicking. Provider leaves are built by
+// calling their provider again, while leaves supplied as structure pointers keep their instance and have their
+// dependencies injected again, unless they were added with RecreateOnRefresh. Leaves added with KeepOnRefresh are left
+// alone. PreDestroy failures don't stop the tree from growing again, and are returned along with any error from
+// growing it
+func (t *Tree) RefreshE() error {
+	return t.RefreshContext(context.Background())
+}
+
+// RefreshContext refreshes the tree like RefreshE, passing the supplied context to any PreDestroy and PostConstruct
+// functions that accept one. Conditions aren't evaluated again, and child trees keep the instances they were given
+// until they're refreshed themselves
+func (t *Tree) RefreshContext(ctx context.Context) error {
+	ctx, err := t.lockLifecycle(ctx)
+	if err != nil {
+		return err
+	}
+	defer t.lifecycle.Unlock()
+
+	if err := t.checkKept(); err != nil {
+		return err
+	}
+
+	errs := make([]error, 0)
+	if err := t.chop(ctx, t.keptOrNotReady); err != nil {
+		errs = append(errs, err)
+	}
+	if err := t.grow(ctx, t.reset); err != nil {
+		errs = append(errs, err)
+	}
+	return combineErrors(errs)
+}
+
+// keptOrNotReady determines if a leaf should be left alone when the tree is chopped for a refresh
+func (t *Tree) keptOrNotReady(leaf *leaf) bool {
+	return leaf.keep || t.notReady(leaf)
+}
+
+// checkKept makes sure the leaves that are kept on refresh only depend on other kept leaves, so they're never left
+// holding an instance that's been destroyed
+func (t *Tree) checkKept() error {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	for _, leafName := range t.addedLeaves {
+		leaf := t.leaves[leafName]
+		if !leaf.keep {
+			continue
+		}
+		for _, edge := range leaf.graphEdges(t) {
+			if t.owner(edge.to) == t && !edge.to.keep {
+				return fmt.Errorf("%w: leaf %s is kept on refresh, but depends on %s, which isn't", ErrInvalidLeaf,
+					leaf.name, edge.to.name)
+			}
+		}
+	}
+	return nil
+}
+
+// reset puts the leaves that aren't kept back the way they were registered, ready to be grown again. The tree must be
+// locked
+func (t *Tree) reset() error {
+	for _, leafName := range t.addedLeaves {
+		leaf := t.leaves[leafName]
+		if leaf.keep {
+			continue
+		}
+		if err := leaf.reset(t.config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reset throws away the leaf's wiring. Provider leaves are built again when the tree is grown, and leaves added with
+// RecreateOnRefresh start over from a copy of the registered structure. Other singletons keep their instance, with the
+// fields that were injected cleared so they're wired again
+func (l *leaf) reset(config *config) error {
+	l.state = LeafRegistered
+	l.instances = nil
+	if l.scope == prototype {
+		return nil
+	}
+
+	if l.provider != nil {
+		l.structureValue = reflect.Value{}
+		l.structureElement = reflect.Value{}
+		l.provider.arguments = nil
+		l.unresolvedDependencies = map[string]*dependency{}
+		l.resolvedDependencies = map[string]*dependency{}
+		l.postConstruct = nil
+		l.preDestroy = nil
+		return nil
+	}
+
+	if l.recreate {
+		l.structureValue = reflect.New(l.structureType)
+		l.structureValue.Elem().Set(l.registered)
+		l.structureElement = l.structureValue.Elem()
+		return l.initialize(config)
+	}
+
+	for _, dep := range l.resolvedDependencies {
+		dep.value.Set(reflect.Zero(dep.value.Type()))
+	}
+	return l.initializeDependencies(config.tagName)
+}
@@ -0,0 +1,119 @@
+package autumn
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type refreshedServer struct {
+	Port     int            `autumn:"${server.port:8080}"`
+	Recorder *orderRecorder `autumn:""`
+	Name     string
+}
+
+func (r *refreshedServer) PostConstruct() {
+	r.Recorder.events = append(r.Recorder.events, "construct server")
+}
+
+func (r *refreshedServer) PreDestroy() {
+	r.Recorder.events = append(r.Recorder.events, "destroy server")
+}
+
+type refreshedClient struct {
+	Server *refreshedServer `autumn:""`
+}
+
+func TestRefresh(t *testing.T) {
+	Convey("Refreshes the tree", t, func() {
+		recorder := &orderRecorder{}
+		provided := 0
+		tree := NewTree().
+			AddLeaf(recorder, KeepOnRefresh()).
+			AddLeaf(&refreshedServer{Name: "registered"}).
+			AddProvider(func(server *refreshedServer) *refreshedClient {
+				provided++
+				return &refreshedClient{Server: server}
+			}).
+			Grow()
+
+		server := tree.GetLeafValue("autumn.refreshedServer").(*refreshedServer)
+		client := tree.GetLeafValue("autumn.refreshedClient").(*refreshedClient)
+
+		Convey("Destroys the leaves and wires the supplied structures again", func() {
+			server.Name = "changed"
+			tree.SetProperty("server.port", "9090").Refresh()
+
+			So(tree.GetLeafValue("autumn.refreshedServer"), ShouldEqual, server)
+			So(server.Name, ShouldEqual, "changed")
+			So(server.Port, ShouldEqual, 9090)
+			So(server.Recorder, ShouldEqual, recorder)
+			So(recorder.events, ShouldResemble, []string{"construct server", "destroy server", "construct server"})
+			So(tree.Describe("autumn.refreshedServer").State, ShouldEqual, LeafReady)
+		})
+
+		Convey("Calls providers again", func() {
+			tree.Refresh()
+
+			refreshed := tree.GetLeafValue("autumn.refreshedClient").(*refreshedClient)
+			So(provided, ShouldEqual, 2)
+			So(refreshed, ShouldNotEqual, client)
+			So(refreshed.Server, ShouldEqual, tree.GetLeafValue("autumn.refreshedServer"))
+		})
+
+		Convey("Keeps the leaves added with KeepOnRefresh", func() {
+			tree.Refresh()
+			So(tree.GetLeafValue("autumn.orderRecorder"), ShouldEqual, recorder)
+		})
+
+		Convey("Can be repeated", func() {
+			tree.Refresh().Refresh()
+			So(provided, ShouldEqual, 3)
+			So(recorder.events, ShouldHaveLength, 5)
+		})
+	})
+
+	Convey("Recreates the leaves added with RecreateOnRefresh from the registered structures", t, func() {
+		server := &refreshedServer{Name: "registered"}
+		tree := NewTree().AddLeaf(&orderRecorder{}).AddLeaf(server, RecreateOnRefresh()).Grow()
+
+		server.Name = "changed"
+		tree.SetProperty("server.port", "9090").Refresh()
+
+		refreshed := tree.GetLeafValue("autumn.refreshedServer").(*refreshedServer)
+		So(refreshed, ShouldNotEqual, server)
+		So(refreshed.Name, ShouldEqual, "registered")
+		So(refreshed.Port, ShouldEqual, 9090)
+		So(server.Port, ShouldEqual, 8080)
+	})
+
+	Convey("Only copies the registered structures of leaves added with RecreateOnRefresh", t, func() {
+		tree := NewTree().AddLeaf(&orderRecorder{}).AddLeaf(&refreshedServer{}, RecreateOnRefresh())
+		So(tree.GetLeaf("autumn.refreshedServer").registered.IsValid(), ShouldBeTrue)
+		So(tree.GetLeaf("autumn.orderRecorder").registered.IsValid(), ShouldBeFalse)
+	})
+
+	Convey("Fails if a kept leaf depends on a leaf that isn't kept", t, func() {
+		tree := NewTree().
+			AddLeaf(&orderRecorder{}).
+			AddLeaf(&refreshedServer{}, KeepOnRefresh()).
+			Grow()
+
+		err := tree.RefreshE()
+		So(errors.Is(err, ErrInvalidLeaf), ShouldBeTrue)
+		So(err.Error(), ShouldEqual, "invalid leaf: leaf autumn.refreshedServer is kept on refresh, but depends on "+
+			"autumn.orderRecorder, which isn't")
+	})
+
+	Convey("Grows the tree again when PreDestroy fails", t, func() {
+		failing := &failingLifecycle{}
+		tree := NewTree().AddLeaf(failing, RecreateOnRefresh()).Grow()
+		failing.Err = errors.New("failed")
+
+		var lifecycleError *LifecycleError
+		So(errors.As(tree.RefreshE(), &lifecycleError), ShouldBeTrue)
+		So(lifecycleError.Method, ShouldEqual, "PreDestroy")
+		So(tree.GetLeafValue("autumn.failingLifecycle"), ShouldNotEqual, failing)
+	})
+}
@@ -0,0 +1,247 @@
+package autumn
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RemovePolicy decides what happens to the leaves that depend on a leaf being removed
+type RemovePolicy int
+
+const (
+	// RemoveFail refuses to remove a leaf that other leaves depend on
+	RemoveFail RemovePolicy = iota
+
+	// RemoveCascade removes the leaves that depend on the leaf as well, along with the leaves that depend on them
+	RemoveCascade
+
+	// RemoveDetach clears the fields the leaf was injected into, leaving them to be filled in by another leaf the next
+	// time the tree is grown. Leaves that were constructed by a provider that took the leaf can't be detached
+	RemoveDetach
+)
+
+// Remove removes a leaf and its aliases from the tree, calling its PreDestroy function if it's been grown. The policy
+// decides what happens to the leaves that depend on it. Panics if the leaf can't be removed or PreDestroy fails
+func (t *Tree) Remove(name string, policy RemovePolicy) *Tree {
+	return t.must(t.RemoveE(name, policy))
+}
+
+// RemoveE removes a leaf like Remove, returning ErrLeafNotFound if the leaf wasn't added to this tree and ErrLeafInUse
+// if other leaves depend on it and the policy doesn't allow that. PreDestroy failures are returned after the leaves
+// have been removed
+func (t *Tree) RemoveE(name string, policy RemovePolicy) error {
+	return t.RemoveContext(context.Background(), name, policy)
+}
+
+// RemoveContext removes a leaf like RemoveE, passing the supplied context to any PreDestroy functions that accept one.
+// Only the leaves of this tree are checked for dependents, so child trees should stop using the leaf first
+func (t *Tree) RemoveContext(ctx context.Context, name string, policy RemovePolicy) error {
+	ctx, err := t.lockLifecycle(ctx)
+	if err != nil {
+		return err
+	}
+	defer t.lifecycle.Unlock()
+
+	removed, err := t.prepareRemoval(name, policy)
+	if err != nil {
+		return err
+	}
+
+	// Destroy dependents before the leaves they depend on, then take them all out of the tree
+	errs := make([]error, 0)
+	for _, leaf := range removed {
+		if leaf.scope == prototype || t.state(leaf) != LeafReady {
+			continue
+		}
+		if err := leaf.callPreDestroy(ctx, t.configuration()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, leaf := range removed {
+		leaf.state = LeafDestroyed
+		t.delete(leaf)
+	}
+	return combineErrors(errs)
+}
+
+// prepareRemoval finds the leaves to remove with the supplied policy, in the order they should be destroyed, detaching
+// the leaf from its dependents if required
+func (t *Tree) prepareRemoval(name string, policy RemovePolicy) ([]*leaf, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	target, ok := t.leaves[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: no leaf named %s exists", ErrLeafNotFound, name)
+	}
+
+	dependents := t.dependents(target)
+	switch {
+	case len(dependents) == 0:
+		return []*leaf{target}, nil
+	case policy == RemoveCascade:
+		return t.cascade(target), nil
+	case policy == RemoveDetach:
+		return []*leaf{target}, t.detach(target, dependents)
+	}
+
+	names := make([]string, len(dependents))
+	for i, dependent := range dependents {
+		names[i] = dependent.name
+	}
+	return nil, fmt.Errorf("%w: leaf %s is used by %s", ErrLeafInUse, target.name, strings.Join(names, ", "))
+}
+
+// dependents finds the other leaves in the tree that depend on the supplied leaf, in the order they were added. The
+// tree must be locked
+func (t *Tree) dependents(target *leaf) []*leaf {
+	dependents := make([]*leaf, 0)
+	for _, leafName := range t.addedLeaves {
+		leaf := t.leaves[leafName]
+		if leaf == target {
+			continue
+		}
+		for _, edge := range leaf.graphEdges(t) {
+			if edge.to == target {
+				dependents = append(dependents, leaf)
+				break
+			}
+		}
+	}
+	return dependents
+}
+
+// cascade finds the supplied leaf and every leaf that depends on it, directly or indirectly, with dependents before
+// their dependencies. The tree must be locked
+func (t *Tree) cascade(target *leaf) []*leaf {
+	removing := map[*leaf]bool{target: true}
+	queue := []*leaf{target}
+	for len(queue) != 0 {
+		for _, dependent := range t.dependents(queue[0]) {
+			if !removing[dependent] {
+				removing[dependent] = true
+				queue = append(queue, dependent)
+			}
+		}
+		queue = queue[1:]
+	}
+
+	// Singletons are destroyed in the reverse of the lifecycle order, and prototypes don't need destroying
+	removed := make([]*leaf, 0, len(removing))
+	order := t.lifecycleOrder()
+	for i := len(order) - 1; i >= 0; i-- {
+		if removing[order[i]] {
+			removed = append(removed, order[i])
+			delete(removing, order[i])
+		}
+	}
+	for _, leafName := range t.addedLeaves {
+		if leaf := t.leaves[leafName]; removing[leaf] {
+			removed = append(removed, leaf)
+		}
+	}
+	return removed
+}
+
+// detach clears the fields the supplied leaf was injected into. Prototypes resolve their dependencies whenever an
+// instance is created, so they're left alone. The tree must be locked
+func (t *Tree) detach(target *leaf, dependents []*leaf) error {
+	for _, dependent := range dependents {
+		if dependent.provider != nil && dependent.structureValue.IsValid() {
+			for _, argument := range dependent.provider.arguments {
+				if argument == target {
+					return fmt.Errorf("%w: leaf %s was constructed with %s, so it can't be detached", ErrLeafInUse,
+						dependent.name, target.name)
+				}
+			}
+		}
+	}
+
+	for _, dependent := range dependents {
+		if dependent.scope != prototype {
+			dependent.detach(target)
+		}
+	}
+	return nil
+}
+
+// detach clears the fields the supplied leaf was injected into, moving them back to the unresolved dependencies. Groups
+// keep their other members
+func (l *leaf) detach(target *leaf) {
+	for _, dep := range l.sortedDependencies(l.resolvedDependencies) {
+		remaining := make([]*leaf, 0, len(dep.leaves))
+		kept := make([]int, 0, len(dep.leaves))
+		for i, leaf := range dep.leaves {
+			if leaf != target {
+				remaining = append(remaining, leaf)
+				kept = append(kept, i)
+			}
+		}
+		if len(remaining) == len(dep.leaves) {
+			continue
+		}
+
+		if len(remaining) == 0 || !dep.byGroup() {
+			dep.value.Set(reflect.Zero(dep.value.Type()))
+			dep.leaves = nil
+			l.unresolvedDependencies[dep.field] = dep
+			delete(l.resolvedDependencies, dep.field)
+			continue
+		}
+
+		dep.value.Set(dep.without(target, kept))
+		dep.leaves = remaining
+	}
+}
+
+// without builds a copy of the dependency's group collection without the supplied leaf, keeping the slice elements at
+// the supplied indexes
+func (d *dependency) without(target *leaf, kept []int) reflect.Value {
+	current := d.value
+	if current.Kind() == reflect.Map {
+		collection := reflect.MakeMapWithSize(current.Type(), current.Len())
+		iterator := current.MapRange()
+		for iterator.Next() {
+			if iterator.Key().String() != target.name {
+				collection.SetMapIndex(iterator.Key(), iterator.Value())
+			}
+		}
+		return collection
+	}
+
+	collection := reflect.MakeSlice(current.Type(), 0, len(kept))
+	for _, i := range kept {
+		collection = reflect.Append(collection, current.Index(i))
+	}
+	return collection
+}
+
+// state gets the state of a leaf with the tree locked
+func (t *Tree) state(leaf *leaf) LeafState {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return leaf.state
+}
+
+// delete takes a leaf and its aliases out of the tree. The tree must be locked
+func (t *Tree) delete(target *leaf) {
+	for name, leaf := range t.leaves {
+		if leaf == target {
+			delete(t.leaves, name)
+		}
+	}
+
+	remaining := t.addedLeaves[:0]
+	for _, leafName := range t.addedLeaves {
+		if leafName != target.name {
+			remaining = append(remaining, leafName)
+		}
+	}
+	t.addedLeaves = remaining
+}
@@ -0,0 +1,111 @@
+package autumn
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type removedBase struct {
+	Recorder *orderRecorder `autumn:""`
+}
+
+func (r *removedBase) PreDestroy() {
+	r.Recorder.events = append(r.Recorder.events, "destroy base")
+}
+
+type removedDependent struct {
+	Base     *removedBase   `autumn:""`
+	Recorder *orderRecorder `autumn:""`
+}
+
+func (r *removedDependent) PreDestroy() {
+	r.Recorder.events = append(r.Recorder.events, "destroy dependent")
+}
+
+type storeGroup struct {
+	Slice []store          `autumn:"group:stores"`
+	Map   map[string]store `autumn:"group:stores"`
+}
+
+func TestRemove(t *testing.T) {
+	Convey("Removes leaves", t, func() {
+		recorder := &orderRecorder{}
+		tree := NewTree().
+			AddLeaf(recorder).
+			AddLeaf(&removedBase{}).
+			AddLeaf(&removedDependent{}).
+			AddNamedLeaf("top", &struct {
+				Dependent *removedDependent `autumn:""`
+			}{}).
+			AddNamedLeaf("standalone", &child{}).
+			AddAlias("standalone", "alone").
+			Grow()
+
+		Convey("Destroys the leaf and removes its aliases", func() {
+			standalone := tree.GetLeafValue("standalone").(*child)
+			tree.Remove("alone", RemoveFail)
+
+			So(standalone.pdValue, ShouldEqual, 1)
+			So(tree.GetLeaf("standalone"), ShouldBeNil)
+			So(tree.GetLeaf("alone"), ShouldBeNil)
+			So(tree.Leaves(), ShouldHaveLength, 4)
+		})
+
+		Convey("Fails if other leaves depend on the leaf", func() {
+			err := tree.RemoveE("autumn.removedBase", RemoveFail)
+			So(errors.Is(err, ErrLeafInUse), ShouldBeTrue)
+			So(err.Error(), ShouldEqual, "leaf in use: leaf autumn.removedBase is used by autumn.removedDependent")
+			So(tree.GetLeaf("autumn.removedBase"), ShouldNotBeNil)
+		})
+
+		Convey("Removes the dependents as well, destroying dependents first", func() {
+			tree.Remove("autumn.removedBase", RemoveCascade)
+
+			So(recorder.events, ShouldResemble, []string{"destroy dependent", "destroy base"})
+			So(tree.GetLeaf("autumn.removedDependent"), ShouldBeNil)
+			So(tree.GetLeaf("top"), ShouldBeNil)
+			So(tree.Leaves(), ShouldHaveLength, 2)
+		})
+
+		Convey("Detaches the leaf from its dependents, which can be wired to a replacement", func() {
+			dependent := tree.GetLeafValue("autumn.removedDependent").(*removedDependent)
+			tree.Remove("autumn.removedBase", RemoveDetach)
+			So(recorder.events, ShouldResemble, []string{"destroy base"})
+			So(dependent.Base, ShouldBeNil)
+			So(errors.Is(tree.GrowE(), ErrUnresolvedDependencies), ShouldBeTrue)
+
+			replacement := &removedBase{}
+			tree.AddLeaf(replacement).Grow()
+			So(dependent.Base, ShouldEqual, replacement)
+		})
+
+		Convey("Detaches a leaf from groups", func() {
+			group := &storeGroup{}
+			tree := NewTree().
+				AddNamedLeaf("memory", &memoryStore{}, Groups("stores")).
+				AddNamedLeaf("disk", &diskStore{}, Groups("stores")).
+				AddNamedLeaf("group", group).
+				Grow()
+			tree.Remove("memory", RemoveDetach)
+
+			So(group.Slice, ShouldHaveLength, 1)
+			So(group.Slice[0].Store(), ShouldEqual, "disk")
+			So(group.Map, ShouldHaveLength, 1)
+			So(group.Map["disk"].Store(), ShouldEqual, "disk")
+		})
+
+		Convey("Fails to detach a leaf that a provider was called with", func() {
+			tree := NewTree().
+				AddNamedLeaf("memory", &memoryStore{}).
+				AddProvider(func(m *memoryStore) *diskStore { return &diskStore{} }).
+				Grow()
+			So(errors.Is(tree.RemoveE("memory", RemoveDetach), ErrLeafInUse), ShouldBeTrue)
+		})
+
+		Convey("Fails if the leaf doesn't exist", func() {
+			So(errors.Is(tree.RemoveE("missing", RemoveFail), ErrLeafNotFound), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,50 @@
+package autumn
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Run grows the tree and blocks until the context is done or the process receives SIGINT or SIGTERM, then chops the
+// tree down, reporting any PreDestroy calls still running once the configured shutdown timeout has passed as hung. The
+// error is nil after a clean shutdown, so it can be turned straight into an exit code. If the tree can't be grown, the
+// leaves that were post-constructed are chopped down and the growing error is returned along with any PreDestroy
+// failures
+func (t *Tree) Run(ctx context.Context) error {
+	stopping, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := t.GrowContext(stopping); err != nil {
+		errs := []error{err}
+		if err := t.shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+		return combineErrors(errs)
+	}
+
+	// Stop listening as soon as we're asked to stop, so a second signal kills the process as usual
+	<-stopping.Done()
+	stop()
+	return t.shutdown(ctx)
+}
+
+// shutdown chops down the leaves that are ready like ChopContext, giving up once the shutdown timeout has passed. The
+// supplied context is only used for its values, since it's usually been cancelled by now
+func (t *Tree) shutdown(ctx context.Context) error {
+	ctx = context.WithoutCancel(ctx)
+	if timeout := t.configuration().shutdownTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	ctx, err := t.lockLifecycle(ctx)
+	if err != nil {
+		return err
+	}
+	defer t.lifecycle.Unlock()
+
+	return t.chop(ctx, t.notReady)
+}
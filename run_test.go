@@ -0,0 +1,80 @@
+package autumn
+
+import (
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type runHooks struct {
+	start func()
+	stop  func()
+}
+
+func (r *runHooks) PostConstruct() {
+	r.start()
+}
+
+func (r *runHooks) PreDestroy() {
+	if r.stop != nil {
+		r.stop()
+	}
+}
+
+func TestRun(t *testing.T) {
+	Convey("Runs the tree", t, func() {
+		c := &child{}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		Convey("Chops the tree down once the context is done", func() {
+			tree := NewTree().AddLeaf(c).AddLeaf(&runHooks{start: cancel})
+			So(tree.Run(ctx), ShouldBeNil)
+			So(c.pcValue, ShouldEqual, 1)
+			So(c.pdValue, ShouldEqual, 1)
+		})
+
+		Convey("Chops the tree down when the process is asked to stop", func() {
+			if runtime.GOOS == "windows" {
+				return
+			}
+			signal := func() {
+				process, _ := os.FindProcess(os.Getpid())
+				_ = process.Signal(syscall.SIGTERM)
+			}
+
+			tree := NewTree().AddLeaf(c).AddLeaf(&runHooks{start: signal})
+			So(tree.Run(ctx), ShouldBeNil)
+			So(c.pdValue, ShouldEqual, 1)
+		})
+
+		Convey("Gives up on PreDestroy functions after the shutdown timeout", func() {
+			release := make(chan struct{})
+			defer close(release)
+
+			tree := NewTree().
+				Configure(NewConfig().ShutdownTimeout(10 * time.Millisecond)).
+				AddLeaf(&runHooks{start: cancel, stop: func() { <-release }})
+
+			err := tree.Run(ctx)
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "autumn.runHooks PreDestroy")
+		})
+
+		Convey("Chops down the leaves that grew if growing fails", func() {
+			failing := &failingLifecycle{Err: errors.New("failed")}
+			tree := NewTree().AddLeaf(c).AddLeaf(failing)
+
+			var lifecycleErr *LifecycleError
+			So(errors.As(tree.Run(ctx), &lifecycleErr), ShouldBeTrue)
+			So(lifecycleErr.Method, ShouldEqual, "PostConstruct")
+			So(c.pdValue, ShouldEqual, 1)
+		})
+	})
+}
@@ -0,0 +1,198 @@
+package autumn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// LoadJSON loads a property source from a JSON file. Nested objects are flattened into dotted keys and array elements
+// are keyed by index, so {"db": {"hosts": ["a"]}} provides "db.hosts[0]"
+func LoadJSON(fsys fs.FS, path string) (PropertySource, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	object, ok := document.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to load %s: the document must be an object", path)
+	}
+
+	values := make(map[string]string)
+	flattenJSON("", object, values)
+	return NewMapSource(path, values), nil
+}
+
+// flattenJSON flattens a decoded JSON value into the supplied values, using the prefix as its key
+func flattenJSON(prefix string, value interface{}, values map[string]string) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, child := range typed {
+			if len(prefix) != 0 {
+				key = prefix + "." + key
+			}
+			flattenJSON(key, child, values)
+		}
+	case []interface{}:
+		for i, child := range typed {
+			flattenJSON(prefix+"["+strconv.Itoa(i)+"]", child, values)
+		}
+	case nil:
+	default:
+		values[prefix] = fmt.Sprint(typed)
+	}
+}
+
+// LoadProperties loads a property source from a Java-style .properties file. Keys and values are separated by "=",
+// ":" or whitespace, lines starting with "#" or "!" are comments, and a line ending in a backslash continues on the next
+// line
+func LoadProperties(fsys fs.FS, path string) (PropertySource, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	logical := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		if len(logical) == 0 && (len(line) == 0 || line[0] == '#' || line[0] == '!') {
+			continue
+		}
+
+		// An odd number of trailing backslashes continues the line
+		trailing := len(line) - len(strings.TrimRight(line, "\\"))
+		if trailing%2 == 1 {
+			logical += line[:len(line)-1]
+			continue
+		}
+
+		key, value := splitProperty(logical + line)
+		values[key] = value
+		logical = ""
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	if len(logical) != 0 {
+		key, value := splitProperty(logical)
+		values[key] = value
+	}
+
+	return NewMapSource(path, values), nil
+}
+
+// splitProperty splits a .properties line into its unescaped key and value
+func splitProperty(line string) (string, string) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+		} else if strings.ContainsRune("=: \t\f", rune(line[i])) {
+			end = i
+			break
+		}
+	}
+
+	// Skip the whitespace around the separator, and the separator itself
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if len(rest) != 0 && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+
+	return unescapeProperty(line[:end]), unescapeProperty(rest)
+}
+
+// unescapeProperty replaces the escape sequences in a .properties key or value
+func unescapeProperty(value string) string {
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i == len(value)-1 {
+			builder.WriteByte(value[i])
+			continue
+		}
+
+		i++
+		switch value[i] {
+		case 't':
+			builder.WriteByte('\t')
+		case 'n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 'f':
+			builder.WriteByte('\f')
+		case 'u':
+			if i+4 < len(value) {
+				if code, err := strconv.ParseUint(value[i+1:i+5], 16, 32); err == nil {
+					builder.WriteRune(rune(code))
+					i += 4
+					continue
+				}
+			}
+			builder.WriteByte('u')
+		default:
+			builder.WriteByte(value[i])
+		}
+	}
+	return builder.String()
+}
+
+// LoadINI loads a property source from an INI file. Keys in a [section] are prefixed with the section name and a dot,
+// lines starting with ";" or "#" are comments, and values can be wrapped in double quotes
+func LoadINI(fsys fs.FS, path string) (PropertySource, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("failed to load %s: line %d is not a key and value", path, number)
+		}
+
+		key := strings.TrimSpace(parts[0])
+		if len(section) != 0 {
+			key = section + "." + key
+		}
+
+		value := strings.TrimSpace(parts[1])
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	return NewMapSource(path, values), nil
+}
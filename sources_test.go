@@ -0,0 +1,103 @@
+package autumn
+
+import (
+	"testing"
+	"testing/fstest"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var sourceFiles = fstest.MapFS{
+	"config.json": {Data: []byte(`{
+		"db": {"url": "postgres://db", "port": 5432, "debug": true, "replicas": ["a", "b"], "password": null},
+		"ratio": 0.25
+	}`)},
+	"config.properties": {Data: []byte(`# A comment
+! Another comment
+db.url = postgres://db
+db.port: 5432
+db.name    orders
+greeting = hello \
+    world
+path = C:\\data\ttab
+escaped\=key = value
+unicode = \u0041
+`)},
+	"config.ini": {Data: []byte(`; A comment
+top = level
+
+[db]
+url = postgres://db
+# Another comment
+name = "orders"
+`)},
+	"broken.json": {Data: []byte(`[1, 2]`)},
+	"broken.ini":  {Data: []byte(`not a key value pair`)},
+}
+
+// propertyOf looks up a property in the supplied source, failing the test if it's missing
+func propertyOf(source PropertySource, key string) string {
+	value, ok := source.Property(key)
+	So(ok, ShouldBeTrue)
+	return value
+}
+
+func TestLoadJSON(t *testing.T) {
+	Convey("Loads a JSON file", t, func() {
+		source, err := LoadJSON(sourceFiles, "config.json")
+		So(err, ShouldBeNil)
+		So(source.Name(), ShouldEqual, "config.json")
+
+		Convey("Flattens nested objects and arrays", func() {
+			So(propertyOf(source, "db.url"), ShouldEqual, "postgres://db")
+			So(propertyOf(source, "db.port"), ShouldEqual, "5432")
+			So(propertyOf(source, "db.debug"), ShouldEqual, "true")
+			So(propertyOf(source, "db.replicas[1]"), ShouldEqual, "b")
+			So(propertyOf(source, "ratio"), ShouldEqual, "0.25")
+		})
+
+		Convey("Skips null values", func() {
+			_, ok := source.Property("db.password")
+			So(ok, ShouldBeFalse)
+		})
+	})
+
+	Convey("Fails for missing files and non-object documents", t, func() {
+		_, err := LoadJSON(sourceFiles, "missing.json")
+		So(err, ShouldNotBeNil)
+
+		_, err = LoadJSON(sourceFiles, "broken.json")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestLoadProperties(t *testing.T) {
+	Convey("Loads a .properties file", t, func() {
+		source, err := LoadProperties(sourceFiles, "config.properties")
+		So(err, ShouldBeNil)
+
+		So(propertyOf(source, "db.url"), ShouldEqual, "postgres://db")
+		So(propertyOf(source, "db.port"), ShouldEqual, "5432")
+		So(propertyOf(source, "db.name"), ShouldEqual, "orders")
+		So(propertyOf(source, "greeting"), ShouldEqual, "hello world")
+		So(propertyOf(source, "path"), ShouldEqual, "C:\\data\ttab")
+		So(propertyOf(source, "escaped=key"), ShouldEqual, "value")
+		So(propertyOf(source, "unicode"), ShouldEqual, "A")
+	})
+}
+
+func TestLoadINI(t *testing.T) {
+	Convey("Loads an INI file", t, func() {
+		source, err := LoadINI(sourceFiles, "config.ini")
+		So(err, ShouldBeNil)
+
+		So(propertyOf(source, "top"), ShouldEqual, "level")
+		So(propertyOf(source, "db.url"), ShouldEqual, "postgres://db")
+		So(propertyOf(source, "db.name"), ShouldEqual, "orders")
+	})
+
+	Convey("Fails for lines that aren't keys and values", t, func() {
+		_, err := LoadINI(sourceFiles, "broken.ini")
+		So(err, ShouldNotBeNil)
+	})
+}
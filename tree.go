@@ -1,10 +1,34 @@
 package autumn
 
-// Tree defines a set of leaves
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Tree defines a set of leaves. A child tree can use the leaves and properties of its parent. Trees are safe for
+// concurrent use: lookups can run in parallel, while registration and wiring lock the tree, and growing and chopping
+// are serialized. Providers mustn't use the tree, and lifecycle functions and conditions mustn't grow or chop it
 type Tree struct {
+	parent      *Tree
 	config      *config
 	leaves      map[string]*leaf
 	addedLeaves []string
+	environment *Environment
+
+	// mutex guards the tree's fields and the state of its leaves, and lifecycle serializes growing and chopping
+	mutex     sync.RWMutex
+	lifecycle sync.Mutex
+
+	profiles       []string
+	pending        []*leaf
+	pendingAliases map[string][]string
+	report         ConditionReport
+	modules        map[string]bool
 }
 
 // NewTree constructs a new tree
@@ -13,56 +37,322 @@ func NewTree() *Tree {
 		config:      NewConfig(),
 		leaves:      make(map[string]*leaf),
 		addedLeaves: make([]string, 0),
+		environment: NewEnvironment(),
+
+		profiles:       make([]string, 0),
+		pending:        make([]*leaf, 0),
+		pendingAliases: make(map[string][]string),
+		report:         make(ConditionReport, 0),
+		modules:        make(map[string]bool),
 	}
 }
 
+// NewChild constructs a new tree that falls back to this one for leaves and properties it doesn't have. The child shares
+// the configuration of its parent, and its leaves can shadow parent leaves with the same name. Growing and chopping
+// the child only calls the lifecycle functions of its own leaves, so the parent should be grown before the child and
+// chopped after it
+func (t *Tree) NewChild() *Tree {
+	child := NewTree()
+	child.parent = t
+	child.config = t.configuration()
+	child.environment = newChildEnvironment(t.environment)
+	return child
+}
+
+// Parent gets the parent of a child tree, or nil if the tree isn't a child
+func (t *Tree) Parent() *Tree {
+	return t.parent
+}
+
 // Configure configures the tree
 func (t *Tree) Configure(config *config) *Tree {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
 	t.config = config
 	return t
 }
 
-// AddLeaf adds a leaf to the tree
-func (t *Tree) AddLeaf(value interface{}) *Tree {
-	t.checkType(value)
-	return t.add(newLeaf(t.config, value))
+// Environment gets the environment that ${key} fields are filled from, so property sources can be added to it
+func (t *Tree) Environment() *Environment {
+	return t.environment
 }
 
-// AddNamedLeaf adds a named leaf to the tree
-func (t *Tree) AddNamedLeaf(name string, value interface{}) *Tree {
-	t.checkType(value)
-	return t.add(newNamedLeaf(t.config, name, value))
+// SetProperty sets a property value for injection into ${key} fields, overriding every property source
+func (t *Tree) SetProperty(key string, value string) *Tree {
+	t.environment.SetOverride(key, value)
+	return t
 }
 
-// AddAlias adds an alias to a leaf that's already been added
-func (t *Tree) AddAlias(name string, alias ...string) *Tree {
+// ActivateProfiles activates the supplied profiles, in addition to any listed in the autumn.profiles.active property
+func (t *Tree) ActivateProfiles(profiles ...string) *Tree {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 
-	// Make sure the source leaf exists
-	leaf := t.GetLeaf(name)
-	if leaf == nil {
-		panic("Leaf " + name + " does not exist")
+	t.profiles = appendMissing(t.profiles, profiles...)
+	return t
+}
+
+// ActiveProfiles gets the profiles activated on the tree and its parents, followed by the ones listed in the
+// autumn.profiles.active property
+func (t *Tree) ActiveProfiles() []string {
+	t.mutex.RLock()
+	active := appendMissing(make([]string, 0), t.profiles...)
+	t.mutex.RUnlock()
+
+	if t.parent != nil {
+		active = appendMissing(active, t.parent.ActiveProfiles()...)
+	}
+	if value, ok := t.environment.Property(profilesProperty); ok {
+		active = appendMissing(active, splitProfiles(value)...)
+	}
+	return active
+}
+
+// AddLeaf adds a leaf to the tree, panicking if it's invalid or its name is taken
+func (t *Tree) AddLeaf(value interface{}, options ...LeafOption) *Tree {
+	return t.must(t.AddLeafE(value, options...))
+}
+
+// AddLeafE adds a leaf to the tree, returning an error if it's invalid or its name is taken
+func (t *Tree) AddLeafE(value interface{}, options ...LeafOption) error {
+	if err := t.checkType(value); err != nil {
+		return err
 	}
 
+	leaf, err := newLeaf(t.configuration(), value)
+	if err != nil {
+		return err
+	}
+	return t.add(leaf, options...)
+}
+
+// AddNamedLeaf adds a named leaf to the tree, panicking if it's invalid or its name is taken
+func (t *Tree) AddNamedLeaf(name string, value interface{}, options ...LeafOption) *Tree {
+	return t.must(t.AddNamedLeafE(name, value, options...))
+}
+
+// AddNamedLeafE adds a named leaf to the tree, returning an error if it's invalid or its name is taken
+func (t *Tree) AddNamedLeafE(name string, value interface{}, options ...LeafOption) error {
+	if err := t.checkType(value); err != nil {
+		return err
+	}
+
+	leaf, err := newNamedLeaf(t.configuration(), name, value)
+	if err != nil {
+		return err
+	}
+	return t.add(leaf, options...)
+}
+
+// AddProvider adds a leaf that's constructed by the supplied function when the tree is grown. The function may take
+// any number of parameters, each of which is resolved by type from the other leaves in the tree, and must return a
+// structure pointer and optionally an error. Panics if the function is invalid or the leaf name is taken
+func (t *Tree) AddProvider(function interface{}, options ...LeafOption) *Tree {
+	return t.must(t.AddProviderE(function, options...))
+}
+
+// AddProviderE adds a leaf that's constructed by the supplied function when the tree is grown, returning an error if
+// the function is invalid or the leaf name is taken
+func (t *Tree) AddProviderE(function interface{}, options ...LeafOption) error {
+	leaf, err := newProviderLeaf(t.configuration(), function)
+	if err != nil {
+		return err
+	}
+	return t.add(leaf, options...)
+}
+
+// AddNamedProvider adds a named leaf that's constructed by the supplied function when the tree is grown, panicking if
+// the function is invalid or the name is taken
+func (t *Tree) AddNamedProvider(name string, function interface{}, options ...LeafOption) *Tree {
+	return t.must(t.AddNamedProviderE(name, function, options...))
+}
+
+// AddNamedProviderE adds a named leaf that's constructed by the supplied function when the tree is grown, returning an
+// error if the function is invalid or the name is taken
+func (t *Tree) AddNamedProviderE(name string, function interface{}, options ...LeafOption) error {
+	leaf, err := newNamedProviderLeaf(t.configuration(), name, function)
+	if err != nil {
+		return err
+	}
+	return t.add(leaf, options...)
+}
+
+// AddAlias adds an alias to a leaf that's already been added, panicking if the leaf doesn't exist or an alias is taken.
+// Aliases for conditional leaves are added when the tree is grown, if one of the leaves is added
+func (t *Tree) AddAlias(name string, alias ...string) *Tree {
+	return t.must(t.AddAliasE(name, alias...))
+}
+
+// AddAliasE adds an alias to a leaf that's already been added, returning an error if the leaf doesn't exist or an
+// alias is taken
+func (t *Tree) AddAliasE(name string, alias ...string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.addAlias(name, alias...)
+}
+
+// addAlias adds aliases to a leaf like AddAliasE. The tree must be locked
+func (t *Tree) addAlias(name string, alias ...string) error {
+
 	// Make sure some aliases were supplied
 	if len(alias) == 0 {
-		panic("Please supply one or more aliases")
+		return errors.New("please supply one or more aliases")
+	}
+
+	// Make sure the source leaf exists, deferring the aliases for conditional leaves
+	leaf := t.getLeaf(name)
+	if leaf == nil {
+		if !t.isPending(name) {
+			return fmt.Errorf("%w: %s", ErrLeafNotFound, name)
+		}
+		for _, a := range alias {
+			if err := t.checkName(a); err != nil {
+				return err
+			}
+		}
+		t.pendingAliases[name] = append(t.pendingAliases[name], alias...)
+		return nil
 	}
 
 	// Add each alias
 	for _, a := range alias {
 
 		// Make sure a leaf doesn't already exist with the name
-		t.checkName(a)
+		if err := t.checkName(a); err != nil {
+			return err
+		}
 
 		// Add the alias
 		t.leaves[a] = leaf
 	}
 
-	return t
+	return nil
+}
+
+// Install installs the supplied modules, adding their leaves, aliases and property defaults to the tree. Modules that
+// have already been installed in the tree or its parents are skipped. Panics if any of the registrations fail
+func (t *Tree) Install(modules ...*Module) *Tree {
+	return t.must(t.InstallE(modules...))
 }
 
-// Grow loops over the leaves in the tree, setting all dependencies
+// InstallE installs the supplied modules like Install, returning an error if any of the registrations fail. The
+// registrations made before the failure are kept
+func (t *Tree) InstallE(modules ...*Module) error {
+	for _, module := range modules {
+
+		// Mark the module as installed first, so modules that install each other don't loop forever
+		t.mutex.Lock()
+		installed := t.installed(module.name)
+		t.modules[module.name] = true
+		t.mutex.Unlock()
+
+		if installed {
+			continue
+		}
+		if err := module.install(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Grow loops over the leaves in the tree, setting all dependencies, and panics if the tree can't be grown
 func (t *Tree) Grow() *Tree {
+	return t.must(t.GrowE())
+}
+
+// GrowE grows the tree like Grow, returning an error if a leaf can't be constructed, dependencies can't be wired or a
+// PostConstruct function fails. Growing the tree again only wires and post-constructs the leaves that need it
+func (t *Tree) GrowE() error {
+	return t.GrowContext(context.Background())
+}
+
+// GrowContext grows the tree like GrowE, passing the supplied context to any PostConstruct functions that accept one,
+// and stopping with the context error if it's done before every leaf has been post-constructed
+func (t *Tree) GrowContext(ctx context.Context) error {
+	ctx, err := t.lockLifecycle(ctx)
+	if err != nil {
+		return err
+	}
+	defer t.lifecycle.Unlock()
+
+	return t.grow(ctx, nil)
+}
+
+// lifecycleKey is the context key that marks the contexts passed to a tree's lifecycle functions
+type lifecycleKey struct {
+	tree *Tree
+}
+
+// lockLifecycle locks the tree's lifecycle, returning the context to pass to lifecycle functions. Returns ErrReentrant
+// instead if the supplied context came from one of the tree's own lifecycle functions, since the lock is already held
+func (t *Tree) lockLifecycle(ctx context.Context) (context.Context, error) {
+	if ctx.Value(lifecycleKey{tree: t}) != nil {
+		return nil, fmt.Errorf("%w: a lifecycle function can't grow, chop, refresh or remove leaves from its own tree",
+			ErrReentrant)
+	}
+	t.lifecycle.Lock()
+	return context.WithValue(ctx, lifecycleKey{tree: t}, true), nil
+}
+
+// grow grows the tree like GrowContext, calling the supplied function first if there is one. It's called with the tree
+// locked right up until the leaves are wired, so lookups never see what it's done before that. The tree's lifecycle
+// must be locked
+func (t *Tree) grow(ctx context.Context, prepare func() error) error {
+	// Add the conditional leaves whose conditions match
+	if err := t.addPending(); err != nil {
+		return err
+	}
+
+	// Construct and wire the leaves
+	t.mutex.Lock()
+	order, err := t.prepareAndWire(ctx, prepare)
+	t.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Call PostConstruct with dependencies before dependents, stopping at the first failure. The prototype instances a
+	// leaf was injected with are post-constructed just before it
+	for _, leaf := range order {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := leaf.callInstancePostConstructs(ctx, t.configuration()); err != nil {
+			return err
+		}
+		if err := leaf.callPostConstruct(ctx, t.configuration()); err != nil {
+			return err
+		}
+		t.setState(leaf, LeafReady)
+	}
+
+	return nil
+}
+
+// prepareAndWire calls the supplied function if there is one and then wires the tree. The tree must be locked
+func (t *Tree) prepareAndWire(ctx context.Context, prepare func() error) ([]*leaf, error) {
+	if prepare != nil {
+		if err := prepare(); err != nil {
+			return nil, err
+		}
+	}
+	return t.wire(ctx)
+}
+
+// wire constructs the provider leaves and sets the dependencies of every leaf, returning the leaves to call
+// PostConstruct on in order. Leaves that were post-constructed when the tree was last grown are only wired again if they
+// have unresolved dependencies, and are only post-constructed again if some of those are now resolved. The tree must
+// be locked
+func (t *Tree) wire(ctx context.Context) ([]*leaf, error) {
+	// Construct the provider leaves first so they can be wired like any other leaf
+	for _, leafName := range t.addedLeaves {
+		if err := t.getLeaf(leafName).construct(ctx, t, map[*leaf]bool{}); err != nil {
+			return nil, err
+		}
+	}
 
 	// Prepare a list of unresolved leaves so we can print it if required
 	unresolved := make(map[string][]string)
@@ -70,82 +360,484 @@ func (t *Tree) Grow() *Tree {
 	// Loop over the leaves and resolve their dependencies
 	for _, leafName := range t.addedLeaves {
 
-		// Grab the actual leaf
-		leaf := t.GetLeaf(leafName)
+		// Grab the actual leaf, skipping it if it's ready and there's nothing left to wire. Groups that have gained or
+		// lost members since they were injected are wired again
+		leaf := t.getLeaf(leafName)
+		if leaf.scope != prototype {
+			leaf.unwireGroups(t)
+		}
+		if leaf.state == LeafReady && len(leaf.unresolvedDependencies) == 0 {
+			continue
+		}
+
+		// Prototypes are wired whenever an instance is created, so just make sure their dependencies exist
+		if leaf.scope == prototype {
+			if missing := leaf.missingDependencies(t); len(missing) != 0 {
+				unresolved[leaf.name] = missing
+			} else {
+				leaf.state = LeafReady
+			}
+			continue
+		}
 
 		// Resolve the dependencies for the leaf
-		leaf.resolveDependencies(t)
+		resolved := len(leaf.resolvedDependencies)
+		if err := leaf.resolveDependencies(context.WithValue(ctx, injectingKey{}, leaf), t); err != nil {
+			return nil, err
+		}
 
-		// If the leaf has some outstanding dependencies, store those so we can print a nice error
-		if !leaf.dependenciesResolved() {
-			unresolved[leaf.name] = []string{}
-			for name := range leaf.unresolvedDependencies {
-				unresolved[leaf.name] = append(unresolved[leaf.name], name)
-			}
+		// If the leaf has some outstanding dependencies, store those so we can print a nice error. Missing optional
+		// dependencies are left at their zero value, and ready leaves only need post-constructing again if some of them
+		// have been filled in
+		if missing := leaf.missingDependencies(t); len(missing) != 0 {
+			unresolved[leaf.name] = missing
+		} else if leaf.state != LeafReady || len(leaf.resolvedDependencies) != resolved {
+			leaf.state = LeafWired
 		}
 	}
 
-	// If we have some unresolved dependencies, print a failure
+	// If we have some unresolved dependencies, describe them in the error
 	if len(unresolved) != 0 {
-		err := "Failed to wire the following dependencies: \n"
-		for leaf, deps := range unresolved {
-			err += "- " + leaf + " \n"
-			for _, dep := range deps {
-				err += "    - " + dep + "\n"
+		names := make([]string, 0, len(unresolved))
+		for name := range unresolved {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		message := ""
+		for _, name := range names {
+			message += "\n- " + name
+			for _, dep := range unresolved[name] {
+				message += "\n    - " + dep
 			}
 		}
-		panic(err)
+		return nil, fmt.Errorf("%w:%s", ErrUnresolvedDependencies, message)
 	}
 
-	// Loop over the leaves again and call PostConstruct
-	for _, leafName := range t.addedLeaves {
-		t.GetLeaf(leafName).callPostConstruct()
+	// Only post-construct the leaves that were wired this time
+	order := make([]*leaf, 0)
+	for _, leaf := range t.lifecycleOrder() {
+		if leaf.state == LeafWired {
+			order = append(order, leaf)
+		}
 	}
+	return order, nil
+}
 
-	return t
+// ConditionReport describes why each conditional leaf was or wasn't added to the tree when it was grown
+func (t *Tree) ConditionReport() ConditionReport {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return append(ConditionReport{}, t.report...)
 }
 
-// GetLeaf gets a leaf in the tree by name
+// GetLeaf gets a leaf in the tree by name, falling back to the parent tree if there's no such leaf. Use Describe for a
+// read-only view of the leaf, or GetLeafValue for its value
 func (t *Tree) GetLeaf(name string) *leaf {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return t.getLeaf(name)
+}
+
+// getLeaf gets a leaf in the tree by name like GetLeaf. The tree must be locked
+func (t *Tree) getLeaf(name string) *leaf {
 	leaf, ok := t.leaves[name]
-	if !ok {
+	if !ok && t.parent != nil {
+		return t.parent.GetLeaf(name)
+	} else if !ok {
 		return nil
 	}
 	return leaf
 }
 
-// Chop chops down the tree, calling pre-destroy on all the leaves that have it in reverse order
+// GetLeafValue gets the structure pointer for a leaf in the tree by name, or nil if the leaf doesn't exist or its value
+// can't be created. Use GetLeafValueE to find out why a lookup failed
+func (t *Tree) GetLeafValue(name string) interface{} {
+	value, err := t.GetLeafValueE(name)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+// GetLeafValueE gets the structure pointer for a leaf in the tree by name like GetLeafValue, returning ErrLeafNotFound
+// if the leaf doesn't exist and the error from creating its value if it's a prototype that can't be created. Prototype
+// leaves return a new, wired instance on every call, which is created with the tree locked for reading and then
+// post-constructed with the tree unlocked
+func (t *Tree) GetLeafValueE(name string) (interface{}, error) {
+	leaf := t.GetLeaf(name)
+	if leaf == nil {
+		return nil, fmt.Errorf("%w: no leaf named %s exists", ErrLeafNotFound, name)
+	}
+
+	value, err := t.value(leaf)
+	if err != nil {
+		return nil, err
+	}
+	return value.Interface(), nil
+}
+
+// value gets the value of the supplied leaf for a lookup. Prototype instances are created and wired with the tree
+// locked for reading, but their PostConstruct functions are only called once it's been unlocked, so they can use the
+// tree themselves. The tree mustn't be locked
+func (t *Tree) value(target *leaf) (reflect.Value, error) {
+
+	// Collect the prototype instances created for the lookup, like a leaf being wired while the tree is grown
+	lookup := &leaf{}
+	ctx := context.WithValue(context.Background(), injectingKey{}, lookup)
+
+	t.mutex.RLock()
+	value, err := target.instance(ctx, t)
+	t.mutex.RUnlock()
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	if err := lookup.callInstancePostConstructs(ctx, t.configuration()); err != nil {
+		return reflect.Value{}, err
+	}
+	return value, nil
+}
+
+// findByType finds all the leaves that can be assigned to the supplied type, in the order they were added
+func (t *Tree) findByType(target reflect.Type) []*leaf {
+	found := make([]*leaf, 0)
+	for _, leafName := range t.addedLeaves {
+		leaf := t.leaves[leafName]
+		if leaf.pointerType().AssignableTo(target) {
+			found = append(found, leaf)
+		}
+	}
+	return found
+}
+
+// findGroup finds all the leaves in the supplied group, starting with the parent tree's leaves that haven't been
+// shadowed and followed by this tree's leaves in the order they were added
+func (t *Tree) findGroup(group string) []*leaf {
+	found := make([]*leaf, 0)
+	if t.parent != nil {
+		t.parent.mutex.RLock()
+		inherited := t.parent.findGroup(group)
+		t.parent.mutex.RUnlock()
+
+		for _, leaf := range inherited {
+			if _, shadowed := t.leaves[leaf.name]; !shadowed {
+				found = append(found, leaf)
+			}
+		}
+	}
+	for _, leafName := range t.addedLeaves {
+		leaf := t.leaves[leafName]
+		if leaf.inGroup(group) {
+			found = append(found, leaf)
+		}
+	}
+	return found
+}
+
+// resolveType finds the single leaf that can be assigned to the supplied type, which may be an interface. If several
+// leaves match, the one marked as primary is used. The parent tree is only searched if no leaves in this tree match. An
+// error is returned if there are no matches, or several matches and no single primary leaf
+func (t *Tree) resolveType(target reflect.Type) (*leaf, error) {
+	candidates := t.findByType(target)
+	switch len(candidates) {
+	case 0:
+		if t.parent != nil {
+			t.parent.mutex.RLock()
+			defer t.parent.mutex.RUnlock()
+			return t.parent.resolveType(target)
+		}
+		return nil, fmt.Errorf("%w: no leaf of type %s exists", ErrLeafNotFound, target.String())
+	case 1:
+		return candidates[0], nil
+	}
+
+	primaries := make([]*leaf, 0)
+	for _, candidate := range candidates {
+		if candidate.primary {
+			primaries = append(primaries, candidate)
+		}
+	}
+	if len(primaries) == 1 {
+		return primaries[0], nil
+	}
+
+	names := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		names[i] = candidate.name
+	}
+	return nil, errors.New("multiple leaves of type " + target.String() + " exist: " + strings.Join(names, ", "))
+}
+
+// Chop chops down the tree, calling pre-destroy on all the leaves that have it in the reverse of the PostConstruct
+// order, so dependents are destroyed before their dependencies. Leaves that have already been chopped down are skipped,
+// so chopping twice does nothing the second time. Prototype instances aren't tracked by the tree, so they're never
+// pre-destroyed. Panics if any PreDestroy function fails
 func (t *Tree) Chop() *Tree {
-	for i := len(t.addedLeaves) - 1; i >= 0; i-- {
-		t.GetLeaf(t.addedLeaves[i]).callPreDestroy()
+	return t.must(t.ChopE())
+}
+
+// ChopE chops down the tree like Chop. Every leaf gets a chance to clean up, even if others fail, and the failures are
+// returned together
+func (t *Tree) ChopE() error {
+	return t.ChopContext(context.Background())
+}
+
+// ChopContext chops down the tree like ChopE, passing the supplied context to any PreDestroy functions that accept one.
+// Every PreDestroy function is called even if the context is done or an earlier leaf hung, but the tree stops waiting
+// for each one once its timeout passes or the context is done, reporting it in the returned errors
+func (t *Tree) ChopContext(ctx context.Context) error {
+	ctx, err := t.lockLifecycle(ctx)
+	if err != nil {
+		return err
+	}
+	defer t.lifecycle.Unlock()
+
+	return t.chop(ctx, t.destroyed)
+}
+
+// destroyed determines if a leaf has already been chopped down, so it isn't destroyed twice
+func (t *Tree) destroyed(leaf *leaf) bool {
+	return t.state(leaf) == LeafDestroyed
+}
+
+// notReady determines if a leaf hasn't finished growing, so it shouldn't be destroyed
+func (t *Tree) notReady(leaf *leaf) bool {
+	return t.state(leaf) != LeafReady
+}
+
+// chop chops down the tree like ChopContext, leaving out the leaves the supplied function skips. The tree's lifecycle
+// must be locked
+func (t *Tree) chop(ctx context.Context, skip func(leaf *leaf) bool) error {
+	t.mutex.RLock()
+	order := t.lifecycleOrder()
+	t.mutex.RUnlock()
+
+	errs := make([]error, 0)
+	for i := len(order) - 1; i >= 0; i-- {
+		if skip(order[i]) {
+			continue
+		}
+		if err := order[i].callPreDestroy(ctx, t.configuration()); err != nil {
+			errs = append(errs, err)
+		}
+		t.setState(order[i], LeafDestroyed)
+	}
+	return combineErrors(errs)
+}
+
+// lifecycleOrder sorts the tree's own singleton leaves depth-first, so every leaf comes after the leaves it's been wired
+// to. Cycles are broken the way the README's lifecycle ordering section describes
+func (t *Tree) lifecycleOrder() []*leaf {
+	order := make([]*leaf, 0, len(t.addedLeaves))
+	visited := make(map[*leaf]bool)
+
+	var visit func(leaf *leaf)
+	visit = func(leaf *leaf) {
+		if visited[leaf] || t.owner(leaf) != t {
+			return
+		}
+		visited[leaf] = true
+
+		if leaf.scope == prototype {
+			for _, edge := range leaf.graphEdges(t) {
+				visit(edge.to)
+			}
+			return
+		}
+
+		for _, dep := range leaf.dependencyLeaves() {
+			visit(dep)
+		}
+		order = append(order, leaf)
+	}
+
+	for _, leafName := range t.addedLeaves {
+		visit(t.leaves[leafName])
+	}
+	return order
+}
+
+// configuration gets the tree's configuration
+func (t *Tree) configuration() *config {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return t.config
+}
+
+// setState sets the state of a leaf, locking the tree the leaf belongs to
+func (t *Tree) setState(leaf *leaf, state LeafState) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	leaf.state = state
+}
+
+// installed determines if a module with the supplied name has been installed in the tree or its parents. The tree must
+// be locked
+func (t *Tree) installed(name string) bool {
+	if t.modules[name] {
+		return true
+	} else if t.parent == nil {
+		return false
+	}
+
+	t.parent.mutex.RLock()
+	defer t.parent.mutex.RUnlock()
+	return t.parent.installed(name)
+}
+
+// aliases gets the other names the supplied leaf has in the tree it was added to, sorted alphabetically. The tree must
+// be locked
+func (t *Tree) aliases(leaf *leaf) []string {
+	owner := t.owner(leaf)
+	if owner != t {
+		owner.mutex.RLock()
+		defer owner.mutex.RUnlock()
+	}
+
+	var aliases []string
+	for name, aliased := range owner.leaves {
+		if aliased == leaf && name != leaf.name {
+			aliases = append(aliases, name)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// owner finds the tree the supplied leaf was added to, searching this tree and then its parents. Leaves that aren't in
+// any of them, such as prototype instances, belong to this tree. The tree must be locked
+func (t *Tree) owner(leaf *leaf) *Tree {
+	if owner := t.findOwner(leaf); owner != nil {
+		return owner
+	}
+	return t
+}
+
+// findOwner finds the tree the supplied leaf was added to like owner, returning nil if it's not in any of them. The tree
+// must be locked
+func (t *Tree) findOwner(leaf *leaf) *Tree {
+	if t.leaves[leaf.name] == leaf {
+		return t
+	} else if t.parent == nil {
+		return nil
+	}
+
+	t.parent.mutex.RLock()
+	defer t.parent.mutex.RUnlock()
+	return t.parent.findOwner(leaf)
+}
+
+// must panics if the supplied error is set, and returns the tree otherwise
+func (t *Tree) must(err error) *Tree {
+	if err != nil {
+		panic(err)
 	}
 	return t
 }
 
-// CheckType checks the type of the supplied interface
-func (t *Tree) checkType(value interface{}) {
+// checkType checks the type of the supplied interface
+func (t *Tree) checkType(value interface{}) error {
 	if !isStructurePointer(value) {
-		panic("Please only supply structure pointers to AddLeaf/AddNamedLeaf")
+		return fmt.Errorf("%w: please only supply structure pointers to AddLeaf/AddNamedLeaf", ErrInvalidLeaf)
 	}
+	return nil
 }
 
-// checkName checks if the leaf name already exists
-func (t *Tree) checkName(name string) {
+// checkName checks if the leaf name already exists. The tree must be locked
+func (t *Tree) checkName(name string) error {
 	_, exists := t.leaves[name]
 	if exists {
-		panic("A leaf with name " + name + " already exists")
+		return fmt.Errorf("%w: a leaf with name %s already exists", ErrDuplicateName, name)
 	}
+	return nil
 }
 
-// add adds a leaf to the tree
-func (t *Tree) add(leaf *leaf) *Tree {
+// add locks the tree and adds a leaf to it, applying the supplied options to the leaf. Leaves with conditions are held
+// back until the tree is grown
+func (t *Tree) add(leaf *leaf, options ...LeafOption) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	// Apply the leaf options
+	for _, option := range options {
+		option(leaf)
+	}
+	leaf.keepRegistered()
+
+	// Conditional leaves may share a name, so they're only checked once we know which ones are added
+	if len(leaf.conditions) != 0 {
+		t.pending = append(t.pending, leaf)
+		return nil
+	}
+
+	return t.insert(leaf)
+}
+
+// insert adds a leaf to the leaf map and the ordered list, returning an error if its name is taken. The tree must be
+// locked
+func (t *Tree) insert(leaf *leaf) error {
 
 	// Make sure the name's not in use
-	t.checkName(leaf.name)
+	if err := t.checkName(leaf.name); err != nil {
+		return err
+	}
 
 	// Add the leaf to the leaf map and the ordered list
 	t.leaves[leaf.name] = leaf
 	t.addedLeaves = append(t.addedLeaves, leaf.name)
 
-	return t
+	return nil
+}
+
+// isPending determines if any conditional leaves have the supplied name. The tree must be locked
+func (t *Tree) isPending(name string) bool {
+	for _, leaf := range t.pending {
+		if leaf.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// addPending evaluates the held back conditional leaves in the order they were added, adding the ones whose conditions
+// all match to the tree along with their aliases, and discarding the rest. Each outcome is recorded in the condition
+// report. An error is returned if an added leaf's name or alias is taken. Conditions are evaluated with the tree
+// unlocked, so custom predicates can use it
+func (t *Tree) addPending() error {
+	t.mutex.Lock()
+	pending, aliases := t.pending, t.pendingAliases
+	t.pending, t.pendingAliases = make([]*leaf, 0), make(map[string][]string)
+	t.mutex.Unlock()
+
+	for _, leaf := range pending {
+		if err := t.addConditional(leaf, leaf.evaluateConditions(t), aliases[leaf.name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addConditional records the outcome of a conditional leaf's conditions, adding it to the tree with its aliases if
+// they all matched
+func (t *Tree) addConditional(leaf *leaf, outcome ConditionOutcome, aliases []string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.report = append(t.report, outcome)
+	if !outcome.Included {
+		return nil
+	}
+
+	if err := t.insert(leaf); err != nil {
+		return err
+	}
+	if len(aliases) != 0 {
+		return t.addAlias(leaf.name, aliases...)
+	}
+	return nil
 }
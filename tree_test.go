@@ -1,6 +1,7 @@
 package autumn
 
 import (
+	"errors"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -98,6 +99,18 @@ func TestChop(t *testing.T) {
 
 		So(leaf.pdCount, ShouldEqual, 1)
 	})
+
+	Convey("Doesn't destroy leaves that have already been chopped down", t, func() {
+		leaf := &lifecycleCounter{}
+		tree := NewTree().AddLeaf(leaf)
+
+		tree.Chop().Chop()
+		So(leaf.pdCount, ShouldEqual, 1)
+		So(tree.Describe("autumn.lifecycleCounter").State, ShouldEqual, LeafDestroyed)
+
+		tree.Grow().Chop()
+		So(leaf.pdCount, ShouldEqual, 2)
+	})
 }
 
 func TestAddLeaf(t *testing.T) {
@@ -204,7 +217,7 @@ func TestGrow(t *testing.T) {
 			So(si.S, ShouldEqual, si)
 		})
 
-		Convey("Resolves dependencies in the supplied order", func() {
+		Convey("Calls PostConstruct on dependencies first, regardless of the supplied order", func() {
 			p1 := &parent{}
 			c1 := &child{}
 			p2 := &parent{}
@@ -213,7 +226,7 @@ func TestGrow(t *testing.T) {
 			NewTree().AddLeaf(p1).AddLeaf(c1).Grow()
 			NewTree().AddLeaf(c2).AddLeaf(p2).Grow()
 
-			So(p1.pcValue, ShouldEqual, 1)
+			So(p1.pcValue, ShouldEqual, 2)
 			So(c1.pcValue, ShouldEqual, 1)
 
 			So(p2.pcValue, ShouldEqual, 2)
@@ -253,3 +266,765 @@ func TestGrow(t *testing.T) {
 		})
 	})
 }
+
+type request struct {
+	Child   *child `autumn:"child"`
+	pcCount int
+}
+
+func (r *request) GetLeafName() string {
+	return "request"
+}
+
+func (r *request) PostConstruct() {
+	r.pcCount++
+}
+
+type handler struct {
+	First  *request `autumn:"request"`
+	Second *request `autumn:"secondRequest"`
+}
+
+type circularPrototype struct {
+	Self *circularPrototype `autumn:"circularPrototype"`
+}
+
+func (c *circularPrototype) GetLeafName() string {
+	return "circularPrototype"
+}
+
+func TestPrototype(t *testing.T) {
+	Convey("Creates prototype leaves", t, func() {
+
+		Convey("Injects a new instance at every injection point", func() {
+			h := &handler{}
+			c := &child{}
+			NewTree().
+				AddLeaf(&request{}, Prototype()).
+				AddNamedLeaf("secondRequest", &request{}, Prototype()).
+				AddLeaf(c).
+				AddLeaf(h).
+				Grow()
+
+			So(h.First, ShouldNotBeNil)
+			So(h.Second, ShouldNotBeNil)
+			So(h.First, ShouldNotEqual, h.Second)
+			So(h.First.Child, ShouldEqual, c)
+			So(h.First.pcCount, ShouldEqual, 1)
+		})
+
+		Convey("Creates a new instance on every retrieval", func() {
+			template := &request{}
+			tree := NewTree().AddLeaf(template, Prototype()).AddLeaf(&child{}).Grow()
+
+			first := tree.GetLeafValue("request").(*request)
+			second := tree.GetLeafValue("request").(*request)
+			So(first, ShouldNotEqual, second)
+			So(first, ShouldNotEqual, template)
+			So(first.pcCount, ShouldEqual, 1)
+			So(second.pcCount, ShouldEqual, 1)
+			So(template.pcCount, ShouldEqual, 0)
+		})
+
+		Convey("Calls the provider for every instance", func() {
+			calls := 0
+			tree := NewTree().
+				AddProvider(func() *request { calls++; return &request{} }, Prototype()).
+				AddLeaf(&child{}).
+				Grow()
+
+			So(tree.GetLeafValue("request"), ShouldNotEqual, tree.GetLeafValue("request"))
+			So(calls, ShouldEqual, 2)
+		})
+
+		Convey("Post-constructs injected instances after their dependencies while growing", func() {
+			recorder := &orderRecorder{}
+			tree := NewTree().
+				AddLeaf(&prototypeUser{}).
+				AddLeaf(&prototypeMiddle{}, Prototype()).
+				AddLeaf(&prototypeBottom{}).
+				AddLeaf(recorder).
+				Grow()
+			So(recorder.events, ShouldResemble, []string{"construct bottom", "construct prototype", "construct user"})
+
+			recorder.events = nil
+			tree.GetLeafValue("autumn.prototypeMiddle")
+			So(recorder.events, ShouldResemble, []string{"construct prototype"})
+		})
+
+		Convey("Panics if a prototype dependency can't be found", func() {
+			So(func() {
+				NewTree().AddLeaf(&request{}, Prototype()).Grow()
+			}, ShouldPanic)
+		})
+
+		Convey("Fails if a prototype depends on itself", func() {
+			tree := NewTree().AddLeaf(&circularPrototype{}, Prototype()).Grow()
+			_, err := tree.GetLeafValueE("circularPrototype")
+			So(err, ShouldNotBeNil)
+			So(tree.GetLeafValue("circularPrototype"), ShouldBeNil)
+		})
+	})
+}
+
+func TestGetLeafValue(t *testing.T) {
+	Convey("Gets a leaf value", t, func() {
+
+		Convey("Returns the leaf structure pointer", func() {
+			leaf := &noop{}
+			So(NewTree().AddLeaf(leaf).GetLeafValue("autumn.noop"), ShouldEqual, leaf)
+		})
+
+		Convey("Returns nil if the leaf doesn't exist", func() {
+			So(NewTree().GetLeafValue("a"), ShouldBeNil)
+		})
+
+		Convey("Returns an error if the leaf doesn't exist", func() {
+			value, err := NewTree().GetLeafValueE("a")
+			So(value, ShouldBeNil)
+			So(errors.Is(err, ErrLeafNotFound), ShouldBeTrue)
+		})
+	})
+}
+
+func TestGrowByType(t *testing.T) {
+	Convey("Resolves dependencies by type", t, func() {
+
+		Convey("Injects the only leaf of the field type", func() {
+			leaf := &typed{}
+			b := &bar{}
+			o := &other{}
+			NewTree().AddLeaf(leaf).AddLeaf(b).AddLeaf(o).Grow()
+
+			So(leaf.Bar, ShouldEqual, b)
+			So(leaf.Other, ShouldEqual, o)
+			So(leaf.Named, ShouldEqual, b)
+		})
+
+		Convey("Panics if the type is ambiguous", func() {
+			So(func() {
+				NewTree().AddLeaf(&typed{}).AddLeaf(&other{}).AddLeaf(&bar{}).AddNamedLeaf("b", &bar{}).Grow()
+			}, ShouldPanic)
+		})
+	})
+}
+
+func TestGrowInterfaces(t *testing.T) {
+	Convey("Resolves interface dependencies", t, func() {
+
+		Convey("Injects the implementations", func() {
+			leaf := &storeUser{}
+			m := &memoryStore{}
+			NewTree().AddLeaf(leaf).AddNamedLeaf("memory", m).AddLeaf(&diskStore{}, Primary()).Grow()
+
+			So(leaf.Store.Store(), ShouldEqual, "disk")
+			So(leaf.Memory, ShouldEqual, m)
+		})
+
+		Convey("Panics if a named leaf doesn't implement the interface", func() {
+			So(func() {
+				NewTree().AddLeaf(&storeUser{}).AddNamedLeaf("memory", &bar{}).AddLeaf(&diskStore{}).Grow()
+			}, ShouldPanic)
+		})
+	})
+}
+
+type orderRecorder struct {
+	events []string
+}
+
+type orderedTop struct {
+	Middle   *orderedMiddle `autumn:""`
+	Recorder *orderRecorder `autumn:""`
+}
+
+func (o *orderedTop) PostConstruct() {
+	o.Recorder.events = append(o.Recorder.events, "construct top")
+}
+
+func (o *orderedTop) PreDestroy() {
+	o.Recorder.events = append(o.Recorder.events, "destroy top")
+}
+
+type orderedMiddle struct {
+	Bottom   *orderedBottom `autumn:""`
+	Recorder *orderRecorder `autumn:""`
+}
+
+func (o *orderedMiddle) PostConstruct() {
+	o.Recorder.events = append(o.Recorder.events, "construct middle")
+}
+
+func (o *orderedMiddle) PreDestroy() {
+	o.Recorder.events = append(o.Recorder.events, "destroy middle")
+}
+
+type orderedBottom struct {
+	Top      *orderedTop    `autumn:""`
+	Recorder *orderRecorder `autumn:""`
+}
+
+func (o *orderedBottom) PostConstruct() {
+	o.Recorder.events = append(o.Recorder.events, "construct bottom")
+}
+
+func (o *orderedBottom) PreDestroy() {
+	o.Recorder.events = append(o.Recorder.events, "destroy bottom")
+}
+
+type prototypeUser struct {
+	Prototype *prototypeMiddle `autumn:""`
+	Recorder  *orderRecorder   `autumn:""`
+}
+
+func (p *prototypeUser) PostConstruct() {
+	p.Recorder.events = append(p.Recorder.events, "construct user")
+}
+
+type prototypeMiddle struct {
+	Bottom   *prototypeBottom `autumn:""`
+	Recorder *orderRecorder   `autumn:""`
+}
+
+func (p *prototypeMiddle) PostConstruct() {
+	p.Recorder.events = append(p.Recorder.events, "construct prototype")
+}
+
+type prototypeBottom struct {
+	Recorder *orderRecorder `autumn:""`
+}
+
+func (p *prototypeBottom) PostConstruct() {
+	p.Recorder.events = append(p.Recorder.events, "construct bottom")
+}
+
+func TestLifecycleOrder(t *testing.T) {
+	Convey("Orders the leaf lifecycle by dependencies", t, func() {
+		recorder := &orderRecorder{}
+		tree := NewTree().
+			AddLeaf(&orderedTop{}).
+			AddLeaf(&orderedBottom{}).
+			AddLeaf(&orderedMiddle{}).
+			AddLeaf(recorder)
+
+		Convey("Calls PostConstruct on dependencies before dependents, breaking the cycle at the first leaf", func() {
+			tree.Grow()
+			So(recorder.events, ShouldResemble, []string{"construct bottom", "construct middle", "construct top"})
+		})
+
+		Convey("Calls PreDestroy in the reverse order", func() {
+			tree.Grow()
+			recorder.events = nil
+			tree.Chop()
+			So(recorder.events, ShouldResemble, []string{"destroy top", "destroy middle", "destroy bottom"})
+		})
+	})
+
+	Convey("Orders leaves through the prototypes they depend on", t, func() {
+		recorder := &orderRecorder{}
+		tree := NewTree().
+			AddLeaf(&prototypeUser{}).
+			AddLeaf(&prototypeMiddle{}, Prototype()).
+			AddLeaf(&prototypeBottom{}).
+			AddLeaf(recorder).
+			Grow()
+
+		order := tree.lifecycleOrder()
+		So(order, ShouldHaveLength, 3)
+		So(order[1].name, ShouldEqual, "autumn.prototypeBottom")
+		So(order[2].name, ShouldEqual, "autumn.prototypeUser")
+		So(recorder.events, ShouldResemble, []string{"construct bottom", "construct prototype", "construct user"})
+	})
+
+	Convey("Orders circular dependencies by the order they were added", t, func() {
+		first := NewTree().AddLeaf(&circularFoo{}).AddLeaf(&circularBar{}).Grow().lifecycleOrder()
+		So(first[0].name, ShouldEqual, "circularBar")
+		So(first[1].name, ShouldEqual, "circularFoo")
+
+		second := NewTree().AddLeaf(&circularBar{}).AddLeaf(&circularFoo{}).Grow().lifecycleOrder()
+		So(second[0].name, ShouldEqual, "circularFoo")
+		So(second[1].name, ShouldEqual, "circularBar")
+	})
+}
+
+type badPostConstruct struct{}
+
+func (b *badPostConstruct) PostConstruct(int) {}
+
+type panickingLifecycle struct{}
+
+func (p *panickingLifecycle) PostConstruct() {
+	panic("failed to connect")
+}
+
+func (p *panickingLifecycle) PreDestroy() {
+	panic("failed to disconnect")
+}
+
+func TestErrorReturningMethods(t *testing.T) {
+	Convey("Returns errors instead of panicking", t, func() {
+
+		Convey("Returns an error for a leaf that isn't a structure pointer", func() {
+			So(errors.Is(NewTree().AddLeafE(noop{}), ErrInvalidLeaf), ShouldBeTrue)
+		})
+
+		Convey("Returns an error for an invalid lifecycle signature", func() {
+			So(errors.Is(NewTree().AddLeafE(&badPostConstruct{}), ErrInvalidLeaf), ShouldBeTrue)
+		})
+
+		Convey("Returns an error for a duplicate name", func() {
+			tree := NewTree()
+			So(tree.AddNamedLeafE("a", &noop{}), ShouldBeNil)
+			So(errors.Is(tree.AddNamedLeafE("a", &noop{}), ErrDuplicateName), ShouldBeTrue)
+			So(errors.Is(tree.AddAliasE("a", "a"), ErrDuplicateName), ShouldBeTrue)
+		})
+
+		Convey("Returns an error for an alias to a missing leaf", func() {
+			So(errors.Is(NewTree().AddAliasE("a", "b"), ErrLeafNotFound), ShouldBeTrue)
+		})
+
+		Convey("Returns an error for unresolved dependencies", func() {
+			err := NewTree().AddLeaf(&brokenDependency{}).GrowE()
+			So(errors.Is(err, ErrUnresolvedDependencies), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "no leaf named broken exists")
+		})
+
+		Convey("Returns an error for a failing provider", func() {
+			err := NewTree().AddProvider(func() (*noop, error) { return nil, errors.New("failed") }).GrowE()
+			So(errors.Is(err, ErrConstruction), ShouldBeTrue)
+		})
+
+		Convey("Returns an error for a failing PostConstruct", func() {
+			err := NewTree().AddLeaf(&panickingLifecycle{}).GrowE()
+			So(errors.Is(err, ErrLifecycle), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "failed to connect")
+
+			var panicError *PanicError
+			So(errors.As(err, &panicError), ShouldBeTrue)
+			So(string(panicError.Stack), ShouldContainSubstring, "panickingLifecycle")
+		})
+
+		Convey("Returns the errors for failing PreDestroy functions, after calling all of them", func() {
+			c := &child{}
+			err := NewTree().AddLeaf(&panickingLifecycle{}).AddNamedLeaf("a", &panickingLifecycle{}).AddLeaf(c).ChopE()
+			So(errors.Is(err, ErrLifecycle), ShouldBeTrue)
+			So(err, ShouldHaveLength, 2)
+			So(c.pdValue, ShouldEqual, 1)
+		})
+	})
+}
+
+type optionalDependencies struct {
+	Metrics *noop  `autumn:"metrics,optional"`
+	Tracing *other `autumn:",optional"`
+	Store   store  `autumn:",optional"`
+}
+
+func TestGrowOptional(t *testing.T) {
+	Convey("Resolves optional dependencies", t, func() {
+
+		Convey("Leaves missing dependencies at their zero value", func() {
+			leaf := &optionalDependencies{}
+			So(NewTree().AddLeaf(leaf).GrowE(), ShouldBeNil)
+			So(leaf.Metrics, ShouldBeNil)
+			So(leaf.Tracing, ShouldBeNil)
+		})
+
+		Convey("Injects dependencies that exist", func() {
+			leaf := &optionalDependencies{}
+			metrics := &noop{}
+			tracing := &other{}
+			So(NewTree().AddLeaf(leaf).AddNamedLeaf("metrics", metrics).AddLeaf(tracing).GrowE(), ShouldBeNil)
+			So(leaf.Metrics, ShouldEqual, metrics)
+			So(leaf.Tracing, ShouldEqual, tracing)
+		})
+
+		Convey("Fails if an optional dependency is ambiguous", func() {
+			err := NewTree().AddLeaf(&optionalDependencies{}).AddLeaf(&memoryStore{}).AddLeaf(&diskStore{}).GrowE()
+			So(errors.Is(err, ErrUnresolvedDependencies), ShouldBeTrue)
+		})
+
+		Convey("Fails if an optional dependency has the wrong type", func() {
+			err := NewTree().AddLeaf(&optionalDependencies{}).AddNamedLeaf("metrics", &other{}).GrowE()
+			So(errors.Is(err, ErrUnresolvedDependencies), ShouldBeTrue)
+		})
+	})
+}
+
+type router struct {
+	Handlers   []store          `autumn:"group:handlers"`
+	ByName     map[string]store `autumn:"group:handlers"`
+	Middleware []*noop          `autumn:"group:middleware,optional"`
+}
+
+func TestGrowGroups(t *testing.T) {
+	Convey("Resolves group dependencies", t, func() {
+
+		Convey("Injects every leaf in the group", func() {
+			r := &router{}
+			m := &memoryStore{}
+			d := &diskStore{}
+			So(NewTree().AddLeaf(r).AddLeaf(m, Groups("handlers")).AddLeaf(d, Groups("handlers")).GrowE(), ShouldBeNil)
+
+			So(r.Handlers, ShouldResemble, []store{m, d})
+			So(r.ByName, ShouldResemble, map[string]store{"autumn.memoryStore": m, "autumn.diskStore": d})
+			So(r.Middleware, ShouldBeNil)
+		})
+
+		Convey("Injects leaves that join the group with the groups method", func() {
+			g := &grouped{}
+			holder := &struct {
+				First []*grouped `autumn:"group:first"`
+			}{}
+			So(NewTree().AddNamedLeaf("holder", holder).AddLeaf(g).GrowE(), ShouldBeNil)
+			So(holder.First, ShouldResemble, []*grouped{g})
+		})
+
+		Convey("Constructs dependencies before the groups they're injected into", func() {
+			recorder := &orderRecorder{}
+			holder := &struct {
+				Members []*orderedMiddle `autumn:"group:members"`
+			}{}
+			middle := &orderedMiddle{}
+			tree := NewTree().
+				AddNamedLeaf("holder", holder).
+				AddLeaf(recorder).
+				AddLeaf(middle, Groups("members")).
+				AddLeaf(&orderedBottom{}).
+				AddLeaf(&orderedTop{})
+			So(tree.GrowE(), ShouldBeNil)
+
+			order := tree.lifecycleOrder()
+			So(order[len(order)-1].name, ShouldEqual, "holder")
+		})
+
+		Convey("Injects members that join the group after it's been grown", func() {
+			r := &router{}
+			m := &memoryStore{}
+			d := &diskStore{}
+			tree := NewTree().AddLeaf(r).AddLeaf(m, Groups("handlers")).Grow()
+			So(r.Handlers, ShouldResemble, []store{m})
+
+			So(tree.AddLeaf(d, Groups("handlers")).GrowE(), ShouldBeNil)
+			So(r.Handlers, ShouldResemble, []store{m, d})
+			So(r.ByName, ShouldResemble, map[string]store{"autumn.memoryStore": m, "autumn.diskStore": d})
+			So(tree.Describe("autumn.router").State, ShouldEqual, LeafReady)
+		})
+
+		Convey("Fails to grow again if a late member doesn't fit in the collection", func() {
+			tree := NewTree().AddLeaf(&router{}).AddLeaf(&memoryStore{}, Groups("handlers")).Grow()
+			err := tree.AddLeaf(&noop{}, Groups("handlers")).GrowE()
+			So(errors.Is(err, ErrUnresolvedDependencies), ShouldBeTrue)
+		})
+
+		Convey("Fails if the group is empty", func() {
+			err := NewTree().AddLeaf(&router{}).GrowE()
+			So(errors.Is(err, ErrUnresolvedDependencies), ShouldBeTrue)
+		})
+
+		Convey("Fails if a member doesn't fit in the collection", func() {
+			err := NewTree().AddLeaf(&router{}).AddLeaf(&noop{}, Groups("handlers")).GrowE()
+			So(errors.Is(err, ErrUnresolvedDependencies), ShouldBeTrue)
+		})
+
+		Convey("Fails if the field isn't a collection", func() {
+			holder := &struct {
+				Handler store `autumn:"group:handlers"`
+			}{}
+			So(errors.Is(NewTree().AddNamedLeafE("holder", holder), ErrInvalidLeaf), ShouldBeTrue)
+		})
+	})
+}
+
+func TestGrowProfiles(t *testing.T) {
+	Convey("Adds leaves for the active profiles", t, func() {
+
+		Convey("Only wires the leaves with an active profile", func() {
+			leaf := &storeUser{}
+			NewTree().
+				AddLeaf(leaf).
+				AddNamedLeaf("memory", &memoryStore{}, Profiles("dev", "test")).
+				AddNamedLeaf("memory", &diskStore{}, Profiles("prod")).
+				ActivateProfiles("test").
+				Grow()
+
+			So(leaf.Memory.Store(), ShouldEqual, "memory")
+			So(leaf.Store.Store(), ShouldEqual, "memory")
+		})
+
+		Convey("Activates profiles from the environment", func() {
+			leaf := &storeUser{}
+			tree := NewTree().
+				SetProperty("autumn.profiles.active", "staging, prod").
+				AddLeaf(leaf).
+				AddNamedLeaf("memory", &memoryStore{}, Profiles("dev")).
+				AddNamedLeaf("memory", &diskStore{}, Profiles("prod"))
+			So(tree.GrowE(), ShouldBeNil)
+
+			So(tree.ActiveProfiles(), ShouldResemble, []string{"staging", "prod"})
+			So(leaf.Memory.Store(), ShouldEqual, "disk")
+		})
+
+		Convey("Wires negated profiles when the profile isn't active", func() {
+			leaf := &storeUser{}
+			NewTree().
+				AddLeaf(leaf).
+				AddNamedLeaf("memory", &memoryStore{}, Profiles("!prod")).
+				AddNamedLeaf("memory", &diskStore{}, Profiles("prod")).
+				Grow()
+
+			So(leaf.Memory.Store(), ShouldEqual, "memory")
+		})
+
+		Convey("Adds aliases for the active leaf", func() {
+			tree := NewTree().
+				AddNamedLeaf("memory", &memoryStore{}, Profiles("dev")).
+				AddNamedLeaf("memory", &diskStore{}, Profiles("prod")).
+				AddAlias("memory", "store").
+				ActivateProfiles("prod").
+				Grow()
+
+			So(tree.GetLeafValue("store"), ShouldHaveSameTypeAs, &diskStore{})
+		})
+
+		Convey("Ignores leaves without an active profile", func() {
+			tree := NewTree().AddLeaf(&brokenDependency{}, Profiles("dev"))
+			So(tree.GrowE(), ShouldBeNil)
+			So(tree.GetLeaf("autumn.brokenDependency"), ShouldBeNil)
+		})
+
+		Convey("Fails if several active leaves share a name", func() {
+			err := NewTree().
+				AddNamedLeaf("memory", &memoryStore{}, Profiles("dev")).
+				AddNamedLeaf("memory", &diskStore{}, Profiles("test")).
+				ActivateProfiles("dev", "test").
+				GrowE()
+			So(errors.Is(err, ErrDuplicateName), ShouldBeTrue)
+		})
+	})
+}
+
+func TestGrowConditions(t *testing.T) {
+	Convey("Adds conditional leaves whose conditions match", t, func() {
+
+		Convey("Steps aside for a leaf with the same name", func() {
+			leaf := &storeUser{}
+			tree := NewTree().
+				AddNamedLeaf("memory", &diskStore{}, OnMissingLeaf()).
+				AddLeaf(leaf).
+				AddNamedLeaf("memory", &memoryStore{})
+			So(tree.GrowE(), ShouldBeNil)
+
+			So(leaf.Memory.Store(), ShouldEqual, "memory")
+			So(tree.ConditionReport()[0].Included, ShouldBeFalse)
+		})
+
+		Convey("Adds a default leaf when no leaf has its name", func() {
+			leaf := &storeUser{}
+			NewTree().AddNamedLeaf("memory", &diskStore{}, OnMissingLeaf()).AddLeaf(leaf).Grow()
+			So(leaf.Memory.Store(), ShouldEqual, "disk")
+		})
+
+		Convey("Checks properties", func() {
+			tree := NewTree().
+				SetProperty("store.type", "disk").
+				AddNamedLeaf("disk", &diskStore{}, OnProperty("store.type", "disk", "both")).
+				AddNamedLeaf("memory", &memoryStore{}, OnProperty("store.type", "memory", "both")).
+				AddNamedLeaf("noop", &noop{}, OnProperty("store.type")).
+				Grow()
+
+			So(tree.GetLeaf("disk"), ShouldNotBeNil)
+			So(tree.GetLeaf("memory"), ShouldBeNil)
+			So(tree.GetLeaf("noop"), ShouldNotBeNil)
+		})
+
+		Convey("Checks for present leaves, including conditional leaves added earlier", func() {
+			tree := NewTree().
+				AddNamedLeaf("disk", &diskStore{}, Profiles("prod")).
+				AddNamedLeaf("backup", &noop{}, OnPresentLeaf("disk")).
+				AddNamedLeaf("memory", &memoryStore{}, OnPresentLeaf("disk", "missing")).
+				ActivateProfiles("prod").
+				Grow()
+
+			So(tree.GetLeaf("backup"), ShouldNotBeNil)
+			So(tree.GetLeaf("memory"), ShouldBeNil)
+		})
+
+		Convey("Calls custom predicates", func() {
+			tree := NewTree().
+				AddNamedLeaf("disk", &diskStore{}, OnCondition("always", func(tree *Tree) bool { return true })).
+				AddNamedLeaf("memory", &memoryStore{}, OnCondition("never", func(tree *Tree) bool { return false })).
+				Grow()
+
+			So(tree.GetLeaf("disk"), ShouldNotBeNil)
+			So(tree.GetLeaf("memory"), ShouldBeNil)
+		})
+
+		Convey("Requires every condition to match", func() {
+			tree := NewTree().
+				SetProperty("store.type", "disk").
+				AddNamedLeaf("disk", &diskStore{}, OnProperty("store.type"), Profiles("prod")).
+				Grow()
+
+			So(tree.GetLeaf("disk"), ShouldBeNil)
+			So(tree.ConditionReport(), ShouldResemble, ConditionReport{{
+				Leaf:     "disk",
+				Included: false,
+				Conditions: []ConditionResult{
+					{Matched: true, Reason: "property store.type is set"},
+					{Matched: false, Reason: "none of the profiles prod are active"},
+				},
+			}})
+		})
+	})
+}
+
+type sharedCounter struct {
+	pcCount int
+	pdCount int
+}
+
+func (s *sharedCounter) PostConstruct() {
+	s.pcCount++
+}
+
+func (s *sharedCounter) PreDestroy() {
+	s.pdCount++
+}
+
+type counterUser struct {
+	Counter *sharedCounter `autumn:""`
+	Named   *sharedCounter `autumn:"counter"`
+	Port    int            `autumn:"${port}"`
+}
+
+func TestChildTree(t *testing.T) {
+	Convey("Grows child trees", t, func() {
+		counter := &sharedCounter{}
+		parent := NewTree().AddNamedLeaf("counter", counter).SetProperty("port", "80").Grow()
+
+		Convey("Falls back to the parent for leaves and properties", func() {
+			user := &counterUser{}
+			child := parent.NewChild().AddLeaf(user).Grow()
+
+			So(child.Parent(), ShouldEqual, parent)
+			So(child.GetLeafValue("counter"), ShouldEqual, counter)
+			So(user.Counter, ShouldEqual, counter)
+			So(user.Named, ShouldEqual, counter)
+			So(user.Port, ShouldEqual, 80)
+		})
+
+		Convey("Shadows parent leaves and properties", func() {
+			user := &counterUser{}
+			shadow := &sharedCounter{}
+			child := parent.NewChild().AddNamedLeaf("counter", shadow).AddLeaf(user).SetProperty("port", "8080").Grow()
+
+			So(child.GetLeafValue("counter"), ShouldEqual, shadow)
+			So(parent.GetLeafValue("counter"), ShouldEqual, counter)
+			So(user.Counter, ShouldEqual, shadow)
+			So(user.Named, ShouldEqual, shadow)
+			So(user.Port, ShouldEqual, 8080)
+		})
+
+		Convey("Only calls the lifecycle functions of its own leaves", func() {
+			first := parent.NewChild().AddLeaf(&counterUser{}).Grow()
+			second := parent.NewChild().AddLeaf(&counterUser{}).Grow()
+			first.Chop()
+			second.Chop()
+
+			So(counter.pcCount, ShouldEqual, 1)
+			So(counter.pdCount, ShouldEqual, 0)
+
+			parent.Chop()
+			So(counter.pdCount, ShouldEqual, 1)
+		})
+
+		Convey("Combines groups with the parent's", func() {
+			holder := &struct {
+				Members map[string]*sharedCounter `autumn:"group:counters"`
+			}{}
+			parent := NewTree().
+				AddNamedLeaf("first", &sharedCounter{}, Groups("counters")).
+				AddNamedLeaf("second", &sharedCounter{}, Groups("counters")).
+				Grow()
+			shadow := &sharedCounter{}
+			parent.NewChild().AddNamedLeaf("second", shadow, Groups("counters")).AddNamedLeaf("holder", holder).Grow()
+
+			So(holder.Members, ShouldHaveLength, 2)
+			So(holder.Members["second"], ShouldEqual, shadow)
+		})
+
+		Convey("Inherits the active profiles", func() {
+			parent.ActivateProfiles("dev")
+			child := parent.NewChild().AddNamedLeaf("dev", &noop{}, Profiles("dev")).Grow()
+			So(child.GetLeaf("dev"), ShouldNotBeNil)
+		})
+	})
+}
+
+type countingChild struct {
+	pcCount int
+}
+
+func (c *countingChild) PostConstruct() {
+	c.pcCount++
+}
+
+type optionalPlugin struct {
+	Plugin  *countingChild `autumn:"plugin,optional"`
+	pcCount int
+}
+
+func (o *optionalPlugin) PostConstruct() {
+	o.pcCount++
+}
+
+func TestIncrementalGrow(t *testing.T) {
+	Convey("Grows leaves added after the tree was grown", t, func() {
+		host := &optionalPlugin{}
+		existing := &countingChild{}
+		tree := NewTree().AddNamedLeaf("host", host).AddNamedLeaf("existing", existing).Grow()
+
+		Convey("Only post-constructs the new leaves", func() {
+			late := &countingChild{}
+			tree.AddNamedLeaf("late", late).Grow()
+
+			So(late.pcCount, ShouldEqual, 1)
+			So(existing.pcCount, ShouldEqual, 1)
+			So(host.pcCount, ShouldEqual, 1)
+			So(tree.Describe("late").State, ShouldEqual, LeafReady)
+		})
+
+		Convey("Post-constructs existing leaves again when their optional dependencies are filled in", func() {
+			plugin := &countingChild{}
+			tree.AddNamedLeaf("plugin", plugin).Grow()
+
+			So(host.Plugin, ShouldEqual, plugin)
+			So(host.pcCount, ShouldEqual, 2)
+			So(plugin.pcCount, ShouldEqual, 1)
+			So(existing.pcCount, ShouldEqual, 1)
+		})
+
+		Convey("Does nothing if no leaves were added", func() {
+			tree.Grow()
+			So(host.pcCount, ShouldEqual, 1)
+			So(existing.pcCount, ShouldEqual, 1)
+		})
+
+		Convey("Retries leaves whose PostConstruct failed", func() {
+			failing := &failingLifecycle{Err: errors.New("failed")}
+			So(tree.AddNamedLeafE("failing", failing), ShouldBeNil)
+			So(tree.GrowE(), ShouldNotBeNil)
+
+			failing.Err = nil
+			So(tree.GrowE(), ShouldBeNil)
+			So(tree.Describe("failing").State, ShouldEqual, LeafReady)
+			So(existing.pcCount, ShouldEqual, 1)
+		})
+
+		Convey("Post-constructs every leaf again after the tree was chopped", func() {
+			tree.Chop().Grow()
+			So(existing.pcCount, ShouldEqual, 2)
+		})
+	})
+}
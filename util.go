@@ -1,6 +1,9 @@
 package autumn
 
-import "reflect"
+import (
+	"reflect"
+	"runtime/debug"
+)
 
 // isStructurePointer determines if the supplied value is a structure pointer
 func isStructurePointer(data interface{}) bool {
@@ -25,3 +28,38 @@ func getStructureValue(data interface{}) reflect.Value {
 func getStructureElement(data interface{}) reflect.Value {
 	return reflect.ValueOf(data).Elem()
 }
+
+// isFunction determines if the supplied value is a function
+func isFunction(data interface{}) bool {
+	return reflect.ValueOf(data).Kind() == reflect.Func
+}
+
+// callFunction calls the supplied function, converting a panic into a *PanicError holding the stack trace
+func callFunction(function reflect.Value, arguments []reflect.Value) (results []reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return function.Call(arguments), nil
+}
+
+// copyStructure makes a shallow copy of the structure the supplied pointer points to
+func copyStructure(structurePointer interface{}) reflect.Value {
+	structure := reflect.New(getStructureType(structurePointer)).Elem()
+	structure.Set(getStructureElement(structurePointer))
+	return structure
+}
+
+// sameLeaves determines if the supplied leaf slices hold the same leaves in the same order
+func sameLeaves(first []*leaf, second []*leaf) bool {
+	if len(first) != len(second) {
+		return false
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			return false
+		}
+	}
+	return true
+}
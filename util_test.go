@@ -1,8 +1,11 @@
 package autumn
 
 import (
-	. "github.com/smartystreets/goconvey/convey"
+	"errors"
+	"reflect"
 	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
 )
 
 type testStruct struct{}
@@ -23,3 +26,21 @@ func TestIsStructurePointer(t *testing.T) {
 		})
 	})
 }
+
+func TestCallFunction(t *testing.T) {
+	Convey("Converts a panic into an error", t, func() {
+		_, err := callFunction(reflect.ValueOf(func() { panic("failed to connect") }), []reflect.Value{})
+
+		var panicError *PanicError
+		So(errors.As(err, &panicError), ShouldBeTrue)
+		So(err.Error(), ShouldEqual, "panic: failed to connect")
+		So(panicError.Value, ShouldEqual, "failed to connect")
+		So(string(panicError.Stack), ShouldContainSubstring, "TestCallFunction")
+	})
+
+	Convey("Unwraps panics with an error value", t, func() {
+		failure := errors.New("failed")
+		_, err := callFunction(reflect.ValueOf(func() { panic(failure) }), []reflect.Value{})
+		So(errors.Is(err, failure), ShouldBeTrue)
+	})
+}